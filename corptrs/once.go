@@ -0,0 +1,70 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package corptrs
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrAlreadySet is returned by Once.Set if the value has already been set.
+var ErrAlreadySet = errors.New("corptrs: value already set")
+
+// Once is a write-once cell holding a read-only pointer.
+// The zero value is empty and ready to use.
+// A Once must not be copied after first use.
+type Once[T any] struct {
+	mu   sync.Mutex
+	done chan struct{} // closed when p is set
+	p    Pointer[T]
+}
+
+// Get returns the value, waiting until it is set or ctx is done.
+func (o *Once[T]) Get(ctx context.Context) (Pointer[T], error) {
+	select {
+	case <-o.wait():
+		return o.p, nil
+	case <-ctx.Done():
+		return Pointer[T]{}, ctx.Err()
+	}
+}
+
+// Set sets the value to a copy of v.
+// Set returns ErrAlreadySet if the value has already been set.
+func (o *Once[T]) Set(v T) error {
+	done := o.wait()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	select {
+	case <-done:
+		return ErrAlreadySet
+	default:
+	}
+	o.p = Freeze(&v)
+	close(done)
+	return nil
+}
+
+// TryGet returns the value without waiting.
+// The boolean value ok is false if the value has not been set.
+func (o *Once[T]) TryGet() (p Pointer[T], ok bool) {
+	select {
+	case <-o.wait():
+		return o.p, true
+	default:
+		return Pointer[T]{}, false
+	}
+}
+
+// wait returns the channel that is closed when the value is set.
+func (o *Once[T]) wait() chan struct{} {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.done == nil {
+		o.done = make(chan struct{})
+	}
+	return o.done
+}
@@ -0,0 +1,75 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package corptrs_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/phelmkamp/immut/corptrs"
+)
+
+func ExampleOnce() {
+	var o corptrs.Once[big]
+	go func() {
+		_ = o.Set(big{1, 2, 3, 4, 5})
+	}()
+	p, _ := o.Get(context.Background())
+	fmt.Println(p)
+	// Output: &{1 2 3 4 5}
+}
+
+func TestOnce_Set(t *testing.T) {
+	var o corptrs.Once[int]
+	if p, ok := o.TryGet(); ok || !p.IsNil() {
+		t.Errorf("TryGet() = %v %v, want %v %v", p, ok, nil, false)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs int
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := o.Set(i); err != nil {
+				if !errors.Is(err, corptrs.ErrAlreadySet) {
+					t.Errorf("Set() = %v, want %v", err, corptrs.ErrAlreadySet)
+				}
+				mu.Lock()
+				errs++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if errs != 9 {
+		t.Errorf("Set() failed %d times, want %d", errs, 9)
+	}
+	if p, ok := o.TryGet(); !ok || p.IsNil() {
+		t.Errorf("TryGet() = %v %v, want non-nil %v", p, ok, true)
+	}
+}
+
+func TestOnce_Get(t *testing.T) {
+	var o corptrs.Once[int]
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if p, err := o.Get(ctx); !errors.Is(err, context.DeadlineExceeded) || !p.IsNil() {
+		t.Errorf("Get() = %v %v, want %v %v", p, err, nil, context.DeadlineExceeded)
+	}
+
+	if err := o.Set(42); err != nil {
+		t.Fatal(err)
+	}
+	p, err := o.Get(context.Background())
+	if err != nil || *p.Clone() != 42 {
+		t.Errorf("Get() = %v %v, want %v %v", p, err, 42, nil)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package cowmaps
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/phelmkamp/immut/romaps"
+)
+
+// Concurrent is a copy-on-write map that is safe for concurrent use by multiple goroutines.
+// Reads load the current version through an atomic pointer without locking.
+// Writes are serialized and each publishes a new version, so every operation is linearizable.
+// The zero value is an empty Concurrent. A Concurrent must not be copied after first use.
+type Concurrent[K comparable, V any] struct {
+	mu sync.Mutex // serializes writers
+	p  atomic.Pointer[Map[K, V]]
+}
+
+// Delete deletes the element with the specified key from the map.
+// If there is no such element, delete is a no-op.
+func (c *Concurrent[K, V]) Delete(k K) (v V, ok bool) {
+	c.Write(func(m *Map[K, V]) {
+		v, ok = m.Delete(k)
+	})
+	return
+}
+
+// DoAll does all the supplied operations on the map as a single write
+// and returns the number of operations that changed it (see DoAll).
+func (c *Concurrent[K, V]) DoAll(cap int, ops ...Doer[K, V]) (n int) {
+	c.Write(func(m *Map[K, V]) {
+		n = DoAll(m, cap, ops...)
+	})
+	return
+}
+
+// GetOrSet returns the existing element associated with k, if present.
+// Otherwise, it sets the element associated with k to v and returns v (see GetOrSet).
+func (c *Concurrent[K, V]) GetOrSet(k K, v V) (actual V, loaded bool) {
+	// Avoid locking if key present.
+	if actual, loaded = c.Index(k); loaded {
+		return
+	}
+	c.Write(func(m *Map[K, V]) {
+		actual, loaded = GetOrSet(m, k, v)
+	})
+	return
+}
+
+// Index returns the element associated with k in the current version.
+// The boolean value ok is true if the map contains an element with the specified key.
+func (c *Concurrent[K, V]) Index(k K) (v V, ok bool) {
+	return c.Load().Index(k)
+}
+
+// Len returns the number of elements in the current version.
+func (c *Concurrent[K, V]) Len() int {
+	return c.Load().Len()
+}
+
+// Load returns the current version of the map.
+// It is guaranteed not to change.
+func (c *Concurrent[K, V]) Load() romaps.Map[K, V] {
+	if m := c.p.Load(); m != nil {
+		return m.RO
+	}
+	return romaps.Map[K, V]{}
+}
+
+// SetIndex sets the element associated with k to v.
+func (c *Concurrent[K, V]) SetIndex(k K, v V) {
+	c.Write(func(m *Map[K, V]) {
+		m.SetIndex(k, v)
+	})
+}
+
+// String returns the current version formatted as a string.
+func (c *Concurrent[K, V]) String() string {
+	return c.Load().String()
+}
+
+// Update sets the element associated with k to the result of calling f
+// with the existing element and whether it is present (see Update).
+// f is called while holding the write lock, so it must not write to c.
+func (c *Concurrent[K, V]) Update(k K, f func(old V, ok bool) (V, bool)) {
+	c.Write(func(m *Map[K, V]) {
+		Update(m, k, f)
+	})
+}
+
+// Write calls f with a copy-on-write map of the current version while holding the write lock,
+// then publishes the result as the new version.
+// f must not retain m or write to c.
+func (c *Concurrent[K, V]) Write(f func(m *Map[K, V])) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var m Map[K, V]
+	if p := c.p.Load(); p != nil {
+		m = *p
+	}
+	f(&m)
+	c.p.Store(&m)
+}
+
+// NewConcurrent returns a Concurrent whose initial version is m.
+// m must not be modified afterwards.
+func NewConcurrent[K comparable, V any](m map[K]V) *Concurrent[K, V] {
+	c := &Concurrent[K, V]{}
+	c.p.Store(&Map[K, V]{RO: romaps.Freeze(m)})
+	return c
+}
@@ -23,21 +23,25 @@ func (m *Map[K, V]) Delete(k K) (v V, ok bool) {
 	// Avoid reallocation if key not present.
 	ro := m.RO
 	if v, ok = ro.Index(k); !ok {
+		stats.FastPath(opDelete)
 		return
 	}
 	m2 := romaps.Clone(ro)
+	stats.Clone(opDelete, len(m2))
 	delete(m2, k)
-	m.RO = romaps.Freeze(m2)
+	m.set(m2)
 	return
 }
 
 // SetIndex sets the element associated with k to v.
 // Note: The underlying map is reallocated before the write-operation is performed.
+// To set many elements without reallocating each time, use Transient.
 func (m *Map[K, V]) SetIndex(k K, v V) {
 	ro := m.RO
 	m2 := clone(ro, ro.Len()+1)
+	stats.Clone(opSetIndex, ro.Len())
 	m2[k] = v
-	m.RO = romaps.Freeze(m2)
+	m.set(m2)
 }
 
 // String returns the underlying map formatted as a string.
@@ -56,11 +60,13 @@ func Clear[K comparable, V any](m *Map[K, V]) {
 	// Avoid reallocation if m is empty.
 	ro := m.RO
 	if ro.Len() < 1 {
+		stats.FastPath(opClear)
 		return
 	}
 	// No need to clone just to clear.
 	m2 := make(map[K]V)
-	m.RO = romaps.Freeze(m2)
+	stats.Clone(opClear, 0)
+	m.set(m2)
 }
 
 // Copy copies all key/value pairs in src adding them to dst.
@@ -71,12 +77,14 @@ func Clear[K comparable, V any](m *Map[K, V]) {
 func Copy[K comparable, V any](dst *Map[K, V], src romaps.Map[K, V]) {
 	// Avoid clone if src is empty.
 	if src.Len() < 1 {
+		stats.FastPath(opCopy)
 		return
 	}
 	ro := dst.RO
 	m2 := clone(ro, ro.Len()+src.Len()) // Ensure no additional allocation.
+	stats.Clone(opCopy, ro.Len())
 	romaps.Copy(m2, src)
-	dst.RO = romaps.Freeze(m2)
+	dst.set(m2)
 }
 
 // DeleteFunc deletes any key/value pairs from m for which del returns true.
@@ -85,10 +93,77 @@ func DeleteFunc[K comparable, V any](m *Map[K, V], del func(K, V) bool) {
 	// Avoid clone if pair not present.
 	ro := m.RO
 	if !containsFunc(ro, del) {
+		stats.FastPath(opDeleteFunc)
 		return
 	}
 	m2 := romaps.Clone(ro)
+	stats.Clone(opDeleteFunc, len(m2))
 	maps.DeleteFunc(m2, del)
+	m.set(m2)
+}
+
+// GetOrSet returns the existing element associated with k, if present.
+// Otherwise, it sets the element associated with k to v and returns v.
+// The boolean value loaded is true if the element was present.
+// Note: The underlying map is reallocated before the write-operation is performed.
+func GetOrSet[K comparable, V any](m *Map[K, V], k K, v V) (actual V, loaded bool) {
+	// Avoid reallocation if key present.
+	if actual, loaded = m.RO.Index(k); loaded {
+		stats.FastPath(opGetOrSet)
+		return
+	}
+	m.SetIndex(k, v)
+	return v, false
+}
+
+// Merge copies all key/value pairs in src adding them to dst.
+// When a key in src is already present in dst,
+// the value in dst is replaced by the result of calling resolve
+// with the key, the value in dst and the value in src.
+// If resolve is nil, the value in src is used as with Copy.
+// Values are compared with == if they are comparable (see DoAll).
+// Note: The underlying map is cloned before the first write-operation that changes it is performed.
+func Merge[K comparable, V any](dst *Map[K, V], src romaps.Map[K, V], resolve func(k K, dv, sv V) V) {
+	// Avoid clone if src is empty.
+	if src.Len() < 1 {
+		stats.FastPath(opMerge)
+		return
+	}
+	apply(dst, opMerge, dst.RO.Len()+src.Len(), DoMerge(src, resolve)) // Ensure no additional allocation.
+}
+
+// Rename moves the element associated with from so that it is associated with to,
+// replacing any element already associated with to.
+// It returns false and does nothing if m does not contain from.
+// Note: The underlying map is cloned before the write-operation is performed.
+func Rename[K comparable, V any](m *Map[K, V], from, to K) bool {
+	ro := m.RO
+	v, ok := ro.Index(from)
+	// Avoid clone if from not present or rename has no effect.
+	if !ok || from == to {
+		stats.FastPath(opRename)
+		return ok
+	}
+	m2 := clone(ro, ro.Len())
+	stats.Clone(opRename, ro.Len())
+	delete(m2, from)
+	m2[to] = v
+	m.set(m2)
+	return true
+}
+
+// Update sets the element associated with k to the result of calling f
+// with the existing element and whether it is present.
+// If f returns false, the element is deleted instead.
+// Values are compared with == if they are comparable (see DoAll).
+// Note: The underlying map is reallocated before the write-operation is performed,
+// unless it does not change the map.
+func Update[K comparable, V any](m *Map[K, V], k K, f func(old V, ok bool) (V, bool)) {
+	apply(m, opUpdate, m.RO.Len()+1, DoUpdate(k, f))
+}
+
+// set sets the underlying map to m2, which must be a private copy.
+func (m *Map[K, V]) set(m2 map[K]V) {
 	m.RO = romaps.Freeze(m2)
 }
 
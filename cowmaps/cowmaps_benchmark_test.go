@@ -118,3 +118,19 @@ func runSync(m *sync.Map, n, ratio int, f func(any, bool)) {
 //	runSync(m, n, ratio, f)
 //	fmt.Println(m)
 //}
+
+func BenchmarkSetIndex(b *testing.B) {
+	m := CopyOnWrite(fill(1, N))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.SetIndex(i%N, i)
+	}
+}
+
+func BenchmarkOverlay_SetIndex(b *testing.B) {
+	o := CopyOnWriteOverlay(fill(1, N), 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		o.SetIndex(i%N, i)
+	}
+}
@@ -5,15 +5,18 @@
 package cowmaps_test
 
 import (
+	"expvar"
 	"fmt"
 	"math/rand"
 	"reflect"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/phelmkamp/immut/cowmaps"
 	"github.com/phelmkamp/immut/romaps"
+	"golang.org/x/exp/maps"
 )
 
 func Example() {
@@ -164,3 +167,583 @@ func TestMap_SetIndex(t *testing.T) {
 		t.Errorf("m after SetIndex() = %v, want %v", m, cowmaps.CopyOnWrite(map[int]int{1: -1, 2: 2, 3: 3}))
 	}
 }
+
+func TestMap_copy(t *testing.T) {
+	m := cowmaps.CopyOnWrite(map[int]int{1: 1, 2: 2})
+	m.SetIndex(1, 10)
+	c := m
+	ro := m.RO
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			if v, _ := c.RO.Index(1); v != 10 {
+				t.Errorf("c.RO.Index() = %v, want %v", v, 10)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		m.SetIndex(1, i)
+		m.Delete(2)
+		cowmaps.DoAll(&m, 0, cowmaps.DoSetIndex(2, i))
+	}
+	<-done
+	if want := romaps.Freeze(map[int]int{1: 10, 2: 2}); !romaps.Equal(ro, want) || !romaps.Equal(c.RO, want) {
+		t.Errorf("RO after SetIndex() = %v, want %v", ro, want)
+	}
+	if want := romaps.Freeze(map[int]int{1: 99, 2: 99}); !romaps.Equal(m.RO, want) {
+		t.Errorf("m after SetIndex() = %v, want %v", m.RO, want)
+	}
+}
+
+func TestTransient(t *testing.T) {
+	orig := map[string]int{"a": 1, "b": 2}
+	tm := cowmaps.Transient(cowmaps.CopyOnWrite(orig))
+	for i := 0; i < 3; i++ {
+		tm.SetIndex(strconv.Itoa(i), i)
+	}
+	tm.Delete("a")
+	tm.Do(cowmaps.DoDelete[string, int]("b"))
+	if got, ok := tm.Index("2"); got != 2 || !ok {
+		t.Errorf("Index() = %v %v, want %v %v", got, ok, 2, true)
+	}
+	m := tm.Persistent()
+	if want := romaps.Freeze(map[string]int{"0": 0, "1": 1, "2": 2}); !romaps.Equal(m.RO, want) {
+		t.Errorf("Persistent() = %v, want %v", m, want)
+	}
+	if want := map[string]int{"a": 1, "b": 2}; !reflect.DeepEqual(orig, want) {
+		t.Errorf("orig after Persistent() = %v, want %v", orig, want)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Len() after Persistent() did not panic")
+		}
+	}()
+	tm.Len()
+}
+
+func TestStats(t *testing.T) {
+	cowmaps.EnableStats(true)
+	defer cowmaps.EnableStats(false)
+	cowmaps.ResetStats()
+	defer cowmaps.ResetStats()
+
+	m := cowmaps.CopyOnWrite(map[string]int{"a": 1, "b": 2})
+	m.Delete("c")
+	m.SetIndex("c", 3)
+	m.SetIndex("d", 4)
+	got := cowmaps.Stats()
+	want := map[string]cowmaps.OpStats{
+		"Delete":   {FastPaths: 1},
+		"SetIndex": {Clones: 2, Copied: 5},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Stats() = %v, want %v", got, want)
+	}
+	if v := expvar.Get("cowmaps"); v == nil || v.String() == "" {
+		t.Errorf("expvar.Get() = %v, want non-empty", v)
+	}
+}
+
+func TestCopyOnWriteDeep(t *testing.T) {
+	orig := map[string]map[string]int{"a": {"x": 1}, "b": {"y": 2}}
+	m := cowmaps.CopyOnWriteDeep(orig, func(v map[string]int) map[string]int {
+		return maps.Clone(v)
+	})
+	m2 := m
+	if ok := m2.Update("a", func(v map[string]int) map[string]int {
+		v["x"] = 0
+		return v
+	}); !ok {
+		t.Errorf("Update() = %v, want %v", ok, true)
+	}
+	if ok := m2.Update("c", func(v map[string]int) map[string]int { return v }); ok {
+		t.Errorf("Update() = %v, want %v", ok, false)
+	}
+	if want := map[string]map[string]int{"a": {"x": 1}, "b": {"y": 2}}; !reflect.DeepEqual(orig, want) {
+		t.Errorf("orig after Update() = %v, want %v", orig, want)
+	}
+	got, ok := cowmaps.IndexMap(m2, "a")
+	if want := romaps.Freeze(map[string]int{"x": 0}); !ok || !romaps.Equal(got, want) {
+		t.Errorf("IndexMap() = %v %v, want %v %v", got, ok, want, true)
+	}
+	if got, want := m.Clone(), orig; !reflect.DeepEqual(got, want) {
+		t.Errorf("Clone() = %v, want %v", got, want)
+	}
+	m2.Delete("b")
+	if got := m2.Len(); got != 1 {
+		t.Errorf("Len() = %v, want %v", got, 1)
+	}
+
+	ps := cowmaps.CopyOnWriteDeep(map[int]*int{}, func(p *int) *int {
+		v := *p
+		return &v
+	})
+	v := 1
+	ps.SetIndex(1, &v)
+	if p, ok := cowmaps.IndexPointer(ps, 1); !ok || *p.Clone() != 1 {
+		t.Errorf("IndexPointer() = %v %v, want %v %v", p, ok, 1, true)
+	}
+	if s, ok := cowmaps.IndexSlice(cowmaps.CopyOnWriteDeep(map[int][]int{}, nil), 1); ok || s.Len() != 0 {
+		t.Errorf("IndexSlice() = %v %v, want %v %v", s, ok, "[]", false)
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	orig := make(map[int]int)
+	for i := 0; i < 1_000; i++ {
+		orig[i] = i
+	}
+	r := rand.New(rand.NewSource(1))
+	o := cowmaps.CopyOnWriteOverlay(orig, 0.05)
+	want := maps.Clone(orig)
+	var versions []cowmaps.Overlay[int, int]
+	var wants []map[int]int
+	for i := 0; i < 2_000; i++ {
+		k := r.Intn(1_200)
+		if r.Intn(3) == 0 {
+			got, gotOK := o.Delete(k)
+			v, ok := want[k]
+			delete(want, k)
+			if got != v || gotOK != ok {
+				t.Fatalf("Delete(%v) = %v %v, want %v %v", k, got, gotOK, v, ok)
+			}
+		} else {
+			o.SetIndex(k, -i)
+			want[k] = -i
+		}
+		got, gotOK := o.Index(k)
+		if v, ok := want[k]; got != v || gotOK != ok {
+			t.Fatalf("Index(%v) = %v %v, want %v %v", k, got, gotOK, v, ok)
+		}
+		if i%200 == 0 {
+			versions = append(versions, o)
+			wants = append(wants, maps.Clone(want))
+		}
+	}
+	if got := o.Len(); got != len(want) {
+		t.Errorf("Len() = %v, want %v", got, len(want))
+	}
+	got := make(map[int]int)
+	o.Do(func(k, v int) bool {
+		got[k] = v
+		return true
+	})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Do() = %v, want %v", got, want)
+	}
+	for i, v := range versions {
+		if got := v.RO(); !romaps.Equal(got, romaps.Freeze(wants[i])) {
+			t.Errorf("version %d = %v, want %v", i, got, wants[i])
+		}
+	}
+	o.Compact()
+	if got := o.RO(); !romaps.Equal(got, romaps.Freeze(want)) {
+		t.Errorf("RO() after Compact() = %v, want %v", got, want)
+	}
+	if got := orig[0]; got != 0 {
+		t.Errorf("orig[0] = %v, want %v", got, 0)
+	}
+}
+
+func TestOverlay_zero(t *testing.T) {
+	var o cowmaps.Overlay[string, int]
+	o.SetIndex("a", 1)
+	o.SetIndex("b", 2)
+	o.Delete("a")
+	if got, want := o.String(), "map[b:2]"; got != want {
+		t.Errorf("String() = %v, want %v", got, want)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	orig := map[string]int{"a": 1}
+	m := cowmaps.CopyOnWrite(orig)
+	incr := func(old int, ok bool) (int, bool) { return old + 1, true }
+	cowmaps.Update(&m, "a", incr)
+	cowmaps.Update(&m, "b", incr)
+	if want := romaps.Freeze(map[string]int{"a": 2, "b": 1}); !romaps.Equal(m.RO, want) {
+		t.Errorf("Update() = %v, want %v", m, want)
+	}
+	if want := map[string]int{"a": 1}; !reflect.DeepEqual(orig, want) {
+		t.Errorf("orig after Update() = %v, want %v", orig, want)
+	}
+
+	// deleting a missing key does not clone
+	ro := m.RO
+	cowmaps.Update(&m, "c", func(int, bool) (int, bool) { return 0, false })
+	if !reflect.DeepEqual(m.RO, ro) {
+		t.Errorf("Update() = %v, want %v", m.RO, ro)
+	}
+	cowmaps.Update(&m, "a", func(int, bool) (int, bool) { return 0, false })
+	if want := romaps.Freeze(map[string]int{"b": 1}); !romaps.Equal(m.RO, want) {
+		t.Errorf("Update() = %v, want %v", m, want)
+	}
+}
+
+func TestUpdate_noop(t *testing.T) {
+	cowmaps.EnableStats(true)
+	defer cowmaps.EnableStats(false)
+	cowmaps.ResetStats()
+	defer cowmaps.ResetStats()
+
+	m := cowmaps.CopyOnWrite(map[string]int{"a": 1})
+	cowmaps.Update(&m, "a", func(old int, ok bool) (int, bool) { return old, ok })
+	cowmaps.Update(&m, "b", func(old int, ok bool) (int, bool) { return old, ok })
+	cowmaps.Update(&m, "a", func(old int, ok bool) (int, bool) { return old + 1, ok })
+	if want := romaps.Freeze(map[string]int{"a": 2}); !romaps.Equal(m.RO, want) {
+		t.Errorf("Update() = %v, want %v", m, want)
+	}
+	want := map[string]cowmaps.OpStats{"Update": {Clones: 1, Copied: 1, FastPaths: 2}}
+	if got := cowmaps.Stats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Stats() = %v, want %v", got, want)
+	}
+}
+
+func TestGetOrSet(t *testing.T) {
+	m := cowmaps.CopyOnWrite(map[string]int{"a": 1})
+	ro := m.RO
+	if got, loaded := cowmaps.GetOrSet(&m, "a", 2); got != 1 || !loaded {
+		t.Errorf("GetOrSet() = %v %v, want %v %v", got, loaded, 1, true)
+	}
+	if !reflect.DeepEqual(m.RO, ro) {
+		t.Errorf("m after GetOrSet() = %v, want %v", m.RO, ro)
+	}
+	if got, loaded := cowmaps.GetOrSet(&m, "b", 2); got != 2 || loaded {
+		t.Errorf("GetOrSet() = %v %v, want %v %v", got, loaded, 2, false)
+	}
+	if want := romaps.Freeze(map[string]int{"a": 1}); !romaps.Equal(ro, want) {
+		t.Errorf("ro after GetOrSet() = %v, want %v", ro, want)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	m := cowmaps.CopyOnWrite(map[string]int{"a": 1, "b": 2})
+	cowmaps.Merge(&m, romaps.Freeze(map[string]int{"b": 3, "c": 4}), func(_ string, dv, sv int) int { return dv * sv })
+	if want := romaps.Freeze(map[string]int{"a": 1, "b": 6, "c": 4}); !romaps.Equal(m.RO, want) {
+		t.Errorf("Merge() = %v, want %v", m, want)
+	}
+	cowmaps.Merge(&m, romaps.Freeze(map[string]int{"a": 0}), nil)
+	if want := romaps.Freeze(map[string]int{"a": 0, "b": 6, "c": 4}); !romaps.Equal(m.RO, want) {
+		t.Errorf("Merge() = %v, want %v", m, want)
+	}
+}
+
+func TestMerge_noop(t *testing.T) {
+	cowmaps.EnableStats(true)
+	defer cowmaps.EnableStats(false)
+	cowmaps.ResetStats()
+	defer cowmaps.ResetStats()
+
+	m := cowmaps.CopyOnWrite(map[string]int{"a": 1, "b": 2})
+	cowmaps.Merge(&m, romaps.Freeze(map[string]int{"a": 1}), nil)
+	cowmaps.Merge(&m, romaps.Freeze(map[string]int{"b": 3}), func(_ string, dv, _ int) int { return dv })
+	cowmaps.Merge(&m, romaps.Freeze(map[string]int{"c": 3}), nil)
+	if want := romaps.Freeze(map[string]int{"a": 1, "b": 2, "c": 3}); !romaps.Equal(m.RO, want) {
+		t.Errorf("Merge() = %v, want %v", m, want)
+	}
+	want := map[string]cowmaps.OpStats{"Merge": {Clones: 1, Copied: 2, FastPaths: 2}}
+	if got := cowmaps.Stats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Stats() = %v, want %v", got, want)
+	}
+}
+
+func TestRename(t *testing.T) {
+	orig := map[string]int{"a": 1, "b": 2}
+	m := cowmaps.CopyOnWrite(orig)
+	if ok := cowmaps.Rename(&m, "c", "d"); ok {
+		t.Errorf("Rename() = %v, want %v", ok, false)
+	}
+	if ok := cowmaps.Rename(&m, "a", "b"); !ok {
+		t.Errorf("Rename() = %v, want %v", ok, true)
+	}
+	if want := romaps.Freeze(map[string]int{"b": 1}); !romaps.Equal(m.RO, want) {
+		t.Errorf("Rename() = %v, want %v", m, want)
+	}
+	if want := map[string]int{"a": 1, "b": 2}; !reflect.DeepEqual(orig, want) {
+		t.Errorf("orig after Rename() = %v, want %v", orig, want)
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	c := cowmaps.NewConcurrent(map[string]int{"a": 100, "b": 0})
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	// readers always observe a consistent snapshot
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			m := c.Load()
+			a, _ := m.Index("a")
+			b, _ := m.Index("b")
+			if a+b != 100 {
+				t.Errorf("Load() = %v, want a+b = 100", m)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				c.Write(func(m *cowmaps.Map[string, int]) {
+					a, _ := m.RO.Index("a")
+					b, _ := m.RO.Index("b")
+					cowmaps.DoAll(m, 0, cowmaps.DoSetIndex("a", a-1), cowmaps.DoSetIndex("b", b+1))
+				})
+				c.Update("n", func(old int, _ bool) (int, bool) { return old + 1, true })
+			}
+		}()
+	}
+	wg.Wait()
+	close(done)
+	want := romaps.Freeze(map[string]int{"a": 0, "b": 100, "n": 100})
+	if got := c.Load(); !romaps.Equal(got, want) {
+		t.Errorf("Load() = %v, want %v", got, want)
+	}
+}
+
+func TestConcurrent_zero(t *testing.T) {
+	var c cowmaps.Concurrent[string, int]
+	if got, loaded := c.GetOrSet("a", 1); got != 1 || loaded {
+		t.Errorf("GetOrSet() = %v %v, want %v %v", got, loaded, 1, false)
+	}
+	if got, loaded := c.GetOrSet("a", 2); got != 1 || !loaded {
+		t.Errorf("GetOrSet() = %v %v, want %v %v", got, loaded, 1, true)
+	}
+	snap := c.Load()
+	c.SetIndex("b", 2)
+	if n := c.DoAll(0, cowmaps.DoDelete[string, int]("a")); n != 1 {
+		t.Errorf("DoAll() = %v, want %v", n, 1)
+	}
+	if v, ok := c.Delete("b"); v != 2 || !ok {
+		t.Errorf("Delete() = %v %v, want %v %v", v, ok, 2, true)
+	}
+	if got := c.Len(); got != 0 {
+		t.Errorf("Len() = %v, want %v", got, 0)
+	}
+	if want := romaps.Freeze(map[string]int{"a": 1}); !romaps.Equal(snap, want) {
+		t.Errorf("snap = %v, want %v", snap, want)
+	}
+}
+
+func TestSharded(t *testing.T) {
+	const n = 8
+	s := cowmaps.NewSharded[int, int](n, func(k int) uint64 { return uint64(k) })
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// a single writer updates the shards in order
+		for j := 1; j <= 1_000; j++ {
+			for k := 0; k < n; k++ {
+				s.SetIndex(k, j)
+			}
+		}
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		// a consistent snapshot never observes a later shard ahead of an earlier one
+		snap := s.Snapshot()
+		prev, _ := snap.Index(0)
+		for k := 1; k < n; k++ {
+			v, _ := snap.Index(k)
+			if v > prev || v < prev-1 {
+				t.Fatalf("Snapshot() = %v is inconsistent", snap)
+			}
+			prev = v
+		}
+	}
+	if got := s.Len(); got != n {
+		t.Errorf("Len() = %v, want %v", got, n)
+	}
+	s.Update(0, func(old int, ok bool) (int, bool) { return old + 1, true })
+	if got, loaded := s.GetOrSet(0, 0); got != 1_001 || !loaded {
+		t.Errorf("GetOrSet() = %v %v, want %v %v", got, loaded, 1_001, true)
+	}
+	for k := 1; k < n; k++ {
+		s.Delete(k)
+	}
+	if got, want := s.String(), "map[0:1001]"; got != want {
+		t.Errorf("String() = %v, want %v", got, want)
+	}
+}
+
+func TestVersioned(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	m := cowmaps.NewVersioned(map[int]int{0: 0}, 5)
+	wants := []map[int]int{{0: 0}}
+	for i := 1; i <= 20; i++ {
+		want := maps.Clone(wants[len(wants)-1])
+		ver := m.Update(func(b *cowmaps.Batch[int, int]) {
+			b.SetIndex(10, i)
+			want[10] = i
+			for j := 0; j < 3; j++ {
+				k := r.Intn(10)
+				if r.Intn(2) == 0 {
+					b.Delete(k)
+					delete(want, k)
+				} else {
+					b.SetIndex(k, i)
+					want[k] = i
+				}
+			}
+		})
+		if ver != uint64(i) {
+			t.Fatalf("Update() = %v, want %v", ver, i)
+		}
+		wants = append(wants, want)
+	}
+	if got := m.Oldest(); got != 15 {
+		t.Errorf("Oldest() = %v, want %v", got, 15)
+	}
+	for ver, want := range wants {
+		got, ok := m.AsOf(uint64(ver))
+		if wantOK := ver >= 15; ok != wantOK {
+			t.Fatalf("AsOf(%v) ok = %v, want %v", ver, ok, wantOK)
+		}
+		if ok && !romaps.Equal(got, romaps.Freeze(want)) {
+			t.Errorf("AsOf(%v) = %v, want %v", ver, got, want)
+		}
+	}
+	if _, ok := m.AsOf(21); ok {
+		t.Errorf("AsOf(21) ok = %v, want %v", ok, false)
+	}
+
+	// empty batch does not create a version
+	ver := m.Update(func(b *cowmaps.Batch[int, int]) {
+		b.Delete(100)
+	})
+	if latest, v := m.Latest(); ver != 20 || v != 20 || !romaps.Equal(latest, romaps.Freeze(wants[20])) {
+		t.Errorf("Latest() = %v %v, want %v %v", latest, v, wants[20], 20)
+	}
+}
+
+func TestObservable(t *testing.T) {
+	o := cowmaps.NewObservable(map[string]int{"a": 1})
+	snap, sub := o.Subscribe(10, cowmaps.Block)
+	defer sub.Close()
+	if want := romaps.Freeze(map[string]int{"a": 1}); !romaps.Equal(snap, want) {
+		t.Errorf("Subscribe() = %v, want %v", snap, want)
+	}
+	o.SetIndex("a", 2)
+	o.SetIndex("a", 2) // no change
+	o.SetIndex("b", 1)
+	o.Delete("c") // no change
+	o.Delete("b")
+	if n := o.DoAll(0, cowmaps.DoDelete[string, int]("z")); n != 0 { // no change
+		t.Errorf("DoAll() = %v, want %v", n, 0)
+	}
+	if n := o.DoAll(0, cowmaps.DoDelete[string, int]("a"), cowmaps.DoSetIndex("c", 3)); n != 2 {
+		t.Errorf("DoAll() = %v, want %v", n, 2)
+	}
+	want := []cowmaps.Event[string, int]{
+		{Op: cowmaps.OpUpdate, Key: "a", Old: 1, New: 2},
+		{Op: cowmaps.OpInsert, Key: "b", New: 1},
+		{Op: cowmaps.OpDelete, Key: "b", Old: 1},
+	}
+	for _, w := range want {
+		if got := recv(t, sub); got != w {
+			t.Errorf("<-C = %v, want %v", got, w)
+		}
+	}
+	// DoAll events are unordered
+	got := map[cowmaps.Event[string, int]]bool{recv(t, sub): true, recv(t, sub): true}
+	wantDoAll := map[cowmaps.Event[string, int]]bool{
+		{Op: cowmaps.OpDelete, Key: "a", Old: 2}: true,
+		{Op: cowmaps.OpInsert, Key: "c", New: 3}: true,
+	}
+	if !reflect.DeepEqual(got, wantDoAll) {
+		t.Errorf("<-C = %v, want %v", got, wantDoAll)
+	}
+	if want := romaps.Freeze(map[string]int{"c": 3}); !romaps.Equal(o.Load(), want) {
+		t.Errorf("Load() = %v, want %v", o.Load(), want)
+	}
+}
+
+func TestObservable_DoAll(t *testing.T) {
+	o := cowmaps.NewObservable(map[string]int{"a": 1, "b": 2})
+	_, sub := o.Subscribe(10, cowmaps.Block)
+	defer sub.Close()
+	o.DoAll(0,
+		cowmaps.DoSetIndex("a", 10),
+		cowmaps.DoSetIndex("a", 1), // reverted, so no event
+		cowmaps.DoSetIndex("c", 3),
+		cowmaps.DoDelete[string, int]("c"), // inserted then deleted, so no event
+		cowmaps.DoRename[string, int]("b", "d"),
+	)
+	got := map[cowmaps.Event[string, int]]bool{recv(t, sub): true, recv(t, sub): true}
+	want := map[cowmaps.Event[string, int]]bool{
+		{Op: cowmaps.OpDelete, Key: "b", Old: 2}: true,
+		{Op: cowmaps.OpInsert, Key: "d", New: 2}: true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("<-C = %v, want %v", got, want)
+	}
+
+	// DoFunc may write any key
+	o.DoAll(0, cowmaps.DoFunc(func(m map[string]int) map[string]int {
+		m["a"]++
+		return m
+	}))
+	if got, want := recv(t, sub), (cowmaps.Event[string, int]{Op: cowmaps.OpUpdate, Key: "a", Old: 1, New: 2}); got != want {
+		t.Errorf("<-C = %v, want %v", got, want)
+	}
+	select {
+	case e := <-sub.C:
+		t.Errorf("<-C = %v, want none", e)
+	default:
+	}
+}
+
+// recv receives the next event of sub, failing the test if none arrives in time.
+func recv[K comparable, V any](t *testing.T, sub *cowmaps.Subscription[K, V]) (e cowmaps.Event[K, V]) {
+	t.Helper()
+	select {
+	case e = <-sub.C:
+	case <-time.After(time.Second):
+		t.Fatal("<-C timed out")
+	}
+	return e
+}
+
+func TestObservable_policies(t *testing.T) {
+	o := cowmaps.NewObservableFunc(map[int][]int{}, func(a, b []int) bool { return reflect.DeepEqual(a, b) })
+	_, drop := o.Subscribe(1, cowmaps.Drop)
+	_, disc := o.Subscribe(1, cowmaps.Disconnect)
+	_, block := o.Subscribe(0, cowmaps.Block)
+	go func() {
+		// slow subscriber
+		time.Sleep(10 * time.Millisecond)
+		block.Close()
+	}()
+	for i := 0; i < 3; i++ {
+		o.SetIndex(i, []int{i})
+	}
+	if got := drop.Dropped(); got != 2 {
+		t.Errorf("Dropped() = %v, want %v", got, 2)
+	}
+	if e := <-drop.C; e.Key != 0 {
+		t.Errorf("<-C = %v, want key %v", e, 0)
+	}
+	if e, ok := <-disc.C; !ok || e.Key != 0 {
+		t.Errorf("<-C = %v %v, want key %v", e, ok, 0)
+	}
+	if _, ok := <-disc.C; ok || disc.Dropped() != 1 {
+		t.Errorf("<-C ok = %v, Dropped() = %v, want %v %v", ok, disc.Dropped(), false, 1)
+	}
+	if _, ok := <-block.C; ok {
+		t.Errorf("<-C ok = %v, want %v", ok, false)
+	}
+	drop.Close()
+	disc.Close()
+}
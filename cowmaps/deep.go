@@ -0,0 +1,110 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package cowmaps
+
+import (
+	"fmt"
+
+	"github.com/phelmkamp/immut/corptrs"
+	"github.com/phelmkamp/immut/romaps"
+	"github.com/phelmkamp/immut/roslices"
+)
+
+// DeepMap wraps a copy-on-write map of mutable values, such as pointers, slices or maps.
+// Values are shared between versions, so they are only accessible read-only.
+// Writing to a value through Update clones just that value.
+type DeepMap[K comparable, V any] struct {
+	m     Map[K, V]
+	clone func(V) V
+}
+
+// Clone returns a copy of the map in which every value is cloned.
+func (m DeepMap[K, V]) Clone() map[K]V {
+	ro := m.m.RO
+	m2 := make(map[K]V, ro.Len())
+	ro.Do(func(k K, v V) bool {
+		m2[k] = m.clone(v)
+		return true
+	})
+	return m2
+}
+
+// Delete deletes the element with the specified key from the map.
+// If there is no such element, delete is a no-op.
+// Note: The underlying map is reallocated before the write-operation is performed.
+func (m *DeepMap[K, V]) Delete(k K) {
+	m.m.Delete(k)
+}
+
+// IndexClone returns a clone of the value associated with k.
+// The boolean value ok is true if the map contains an element with the specified key.
+func (m DeepMap[K, V]) IndexClone(k K) (v V, ok bool) {
+	if v, ok = m.m.RO.Index(k); ok {
+		v = m.clone(v)
+	}
+	return
+}
+
+// Keys returns the keys of the map in an indeterminate order.
+func (m DeepMap[K, V]) Keys() []K {
+	return romaps.Keys(m.m.RO)
+}
+
+// Len returns the number of elements in the map.
+func (m DeepMap[K, V]) Len() int {
+	return m.m.RO.Len()
+}
+
+// SetIndex sets the element associated with k to v.
+// v must not be modified afterwards.
+// Note: The underlying map is reallocated before the write-operation is performed.
+func (m *DeepMap[K, V]) SetIndex(k K, v V) {
+	m.m.SetIndex(k, v)
+}
+
+// String returns the underlying map formatted as a string.
+func (m DeepMap[K, V]) String() string {
+	return fmt.Sprint(m.m.RO)
+}
+
+// Update sets the element associated with k to the result of calling f with a clone of its value.
+// f may modify its argument without affecting other versions of the map.
+// Update is a no-op and returns false if the map does not contain k.
+// Note: The underlying map is reallocated before the write-operation is performed.
+func (m *DeepMap[K, V]) Update(k K, f func(V) V) bool {
+	v, ok := m.IndexClone(k)
+	if !ok {
+		return false
+	}
+	m.m.SetIndex(k, f(v))
+	return true
+}
+
+// CopyOnWriteDeep returns a copy-on-write wrapper for the given map
+// that uses clone to copy a value before it is modified.
+func CopyOnWriteDeep[K comparable, V any](m map[K]V, clone func(V) V) DeepMap[K, V] {
+	return DeepMap[K, V]{m: CopyOnWrite(m), clone: clone}
+}
+
+// IndexMap returns the value associated with k as a read-only map.
+// The boolean value ok is true if m contains an element with the specified key.
+func IndexMap[K, K2 comparable, V2 any](m DeepMap[K, map[K2]V2], k K) (romaps.Map[K2, V2], bool) {
+	v, ok := m.m.RO.Index(k)
+	return romaps.Freeze(v), ok
+}
+
+// IndexPointer returns the value associated with k as a read-only pointer.
+// The boolean value ok is true if m contains an element with the specified key.
+func IndexPointer[K comparable, T any](m DeepMap[K, *T], k K) (corptrs.Pointer[T], bool) {
+	v, ok := m.m.RO.Index(k)
+	return corptrs.Freeze(v), ok
+}
+
+// IndexSlice returns the value associated with k as a read-only slice.
+// The boolean value ok is true if m contains an element with the specified key.
+func IndexSlice[K comparable, E any](m DeepMap[K, []E], k K) (roslices.Slice[E], bool) {
+	v, ok := m.m.RO.Index(k)
+	return roslices.Freeze(v), ok
+}
@@ -1,65 +1,379 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
 package cowmaps
 
 import (
+	"math"
+	"reflect"
+
 	"github.com/phelmkamp/immut/romaps"
-	"golang.org/x/exp/maps"
 )
 
 // Doer defines a method for doing an operation on a map.
+// Custom operations may be defined with DoFunc.
 type Doer[K comparable, V any] interface {
-	do(map[K]V) map[K]V
+	do(t *target[K, V])
 }
 
 // doerFunc is a func that implements Doer.
-type doerFunc[K comparable, V any] func(map[K]V) map[K]V
+type doerFunc[K comparable, V any] func(t *target[K, V])
 
-func (f doerFunc[K, V]) do(m map[K]V) map[K]V {
-	return f(m)
+func (f doerFunc[K, V]) do(t *target[K, V]) {
+	f(t)
 }
 
-// DoAll does all the supplied operations on the map with minimal reallocation.
-// The initial capacity of the reallocated map is cap (or len(m) if cap is not sufficient).
-// Note: The underlying map is cloned before the write-operations are performed.
-func DoAll[K comparable, V any](m *Map[K, V], cap int, ops ...Doer[K, V]) {
-	ro := m.RO
-	if cap < ro.Len() {
-		cap = ro.Len()
+// target is the map being modified by a sequence of operations.
+// It reads from ro until the first write that changes the map, which clones ro into m.
+type target[K comparable, V any] struct {
+	ro      romaps.Map[K, V]
+	m       map[K]V // writable map, once cloned
+	cap     int
+	op      int               // operation counted by stats when ro is cloned
+	changed bool              // whether the current operation changed the map
+	prior   undoLog[K, V]     // if non-nil, records the original value of each key written
+	opaque  bool              // whether the map was written by DoFunc, so prior is incomplete
+	same    func(a, b V) bool // reports whether values are identical, or nil if not comparable
+	sameSet bool              // whether same has been determined
+}
+
+// delete deletes the element with the specified key, if present.
+func (t *target[K, V]) delete(k K) {
+	v, ok := t.index(k)
+	if !ok {
+		return
 	}
-	m2 := clone(ro, cap)
+	t.record(k, v, ok)
+	delete(t.writable(), k)
+	t.changed = true
+}
+
+// doAll does ops on m and returns the number of operations that changed it (see DoAll).
+func (t *target[K, V]) doAll(m *Map[K, V], ops []Doer[K, V]) int {
+	t.ro, t.op = m.RO, opDoAll
+	var n int
 	for _, op := range ops {
-		m2 = op.do(m2)
+		t.changed = false
+		op.do(t)
+		if t.changed {
+			n++
+		}
+	}
+	// Avoid reallocation if nothing changed.
+	if n == 0 {
+		stats.FastPath(opDoAll)
+		return n
+	}
+	m.set(t.m)
+	return n
+}
+
+// apply does op on m as a single write that is counted as the operation id,
+// cloning the map with capacity cap only if op changes it.
+func apply[K comparable, V any](m *Map[K, V], id, cap int, op Doer[K, V]) {
+	t := target[K, V]{ro: m.RO, cap: cap, op: id}
+	op.do(&t)
+	if !t.changed {
+		stats.FastPath(id)
+		return
+	}
+	m.set(t.m)
+}
+
+// index returns the element associated with k.
+func (t *target[K, V]) index(k K) (v V, ok bool) {
+	if t.m != nil {
+		v, ok = t.m[k]
+		return
+	}
+	return t.ro.Index(k)
+}
+
+// set sets the element associated with k to v, unless it is already identical to v.
+func (t *target[K, V]) set(k K, v V) {
+	old, ok := t.index(k)
+	if ok && t.identical(old, v) {
+		return
+	}
+	t.record(k, old, ok)
+	t.writable()[k] = v
+	t.changed = true
+}
+
+// record saves the original value of k, if tracking and not already saved.
+func (t *target[K, V]) record(k K, v V, ok bool) {
+	if t.prior == nil {
+		return
+	}
+	if _, found := t.prior[k]; !found {
+		t.prior[k] = prior[V]{v: v, ok: ok}
+	}
+}
+
+// view returns a read-only map of the current elements.
+func (t *target[K, V]) view() romaps.Map[K, V] {
+	if t.m != nil {
+		return romaps.Freeze(t.m)
+	}
+	return t.ro
+}
+
+// writable returns the writable map, cloning ro if necessary.
+func (t *target[K, V]) writable() map[K]V {
+	if t.m == nil {
+		if t.cap < t.ro.Len() {
+			t.cap = t.ro.Len()
+		}
+		t.m = clone(t.ro, t.cap)
+		stats.Clone(t.op, t.ro.Len())
+	}
+	return t.m
+}
+
+// identical reports whether a and b are identical (see identicalFunc).
+// How values are compared is determined once per target.
+func (t *target[K, V]) identical(a, b V) bool {
+	if !t.sameSet {
+		t.same, t.sameSet = identicalFunc[V](), true
+	}
+	return t.same != nil && t.same(a, b)
+}
+
+// identicalFunc returns a function that reports whether two values of type V are identical,
+// or nil if V is not comparable, e.g. a slice or func, so that values are never identical.
+// Values are compared with ==, except that floating-point zeros of opposite sign are not identical.
+func identicalFunc[V any]() func(a, b V) bool {
+	typ := reflect.TypeOf((*V)(nil)).Elem()
+	switch typ.Kind() {
+	case reflect.Interface:
+		return identicalAny[V]
+	case reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		var zero V
+		return func(a, b V) bool {
+			if any(a) != any(b) {
+				return false
+			}
+			// Only zeros may differ in sign.
+			return any(a) != any(zero) || identicalZeros(a, b)
+		}
+	}
+	if !typ.Comparable() {
+		return nil
+	}
+	return func(a, b V) bool {
+		return any(a) == any(b)
+	}
+}
+
+// identicalZeros is like identicalFunc for floating-point or complex zeros.
+func identicalZeros[V any](a, b V) bool {
+	return identicalValue(reflect.ValueOf(&a).Elem(), reflect.ValueOf(&b).Elem())
+}
+
+// identicalAny is like identicalFunc for an interface type V,
+// whose dynamic values may not be comparable.
+func identicalAny[V any](a, b V) (eq bool) {
+	x, y := any(a), any(b)
+	if x == nil || y == nil {
+		return x == y
+	}
+	if reflect.TypeOf(x) != reflect.TypeOf(y) {
+		return false
+	}
+	defer func() {
+		recover() // e.g. a struct containing a slice, which is never identical
+	}()
+	return identicalValue(reflect.ValueOf(x), reflect.ValueOf(y))
+}
+
+// identicalValue reports whether x and y, which have the same type, are identical (see identicalFunc).
+func identicalValue(x, y reflect.Value) bool {
+	switch x.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return identicalFloat(x.Float(), y.Float())
+	case reflect.Complex64, reflect.Complex128:
+		cx, cy := x.Complex(), y.Complex()
+		return identicalFloat(real(cx), real(cy)) && identicalFloat(imag(cx), imag(cy))
 	}
-	m.RO = romaps.Freeze(m2)
+	return x.Interface() == y.Interface()
+}
+
+func identicalFloat(a, b float64) bool {
+	return a == b && math.Signbit(a) == math.Signbit(b)
+}
+
+// DoAll does all the supplied operations on the map with minimal reallocation
+// and returns the number of operations that changed the map.
+// Operations that do not change the map, such as deleting an absent key or setting an identical value,
+// are not counted. Values are compared with == if they are comparable,
+// except that floating-point zeros of opposite sign are not identical, so one may replace the other.
+// Zeros nested in other comparable values, such as structs and arrays, are compared with == alone.
+// The initial capacity of the reallocated map is cap (or len(m) if cap is not sufficient).
+// Note: The underlying map is cloned before the first write-operation that changes it is performed.
+func DoAll[K comparable, V any](m *Map[K, V], cap int, ops ...Doer[K, V]) int {
+	t := target[K, V]{cap: cap}
+	return t.doAll(m, ops)
+}
+
+// DoCompareAndSet returns the operation that sets the element associated with k to new,
+// if it is present and equal to old.
+func DoCompareAndSet[K, V comparable](k K, old, new V) Doer[K, V] {
+	return doerFunc[K, V](func(t *target[K, V]) {
+		if v, ok := t.index(k); ok && v == old {
+			t.set(k, new)
+		}
+	})
 }
 
 // DoCopy returns the maps.Copy operation.
 func DoCopy[K comparable, V any](src map[K]V) Doer[K, V] {
-	return doerFunc[K, V](func(m map[K]V) map[K]V {
-		maps.Copy(m, src)
-		return m
+	return doerFunc[K, V](func(t *target[K, V]) {
+		for k, v := range src {
+			t.set(k, v)
+		}
 	})
 }
 
 // DoDelete returns the delete operation.
 func DoDelete[K comparable, V any](k K) Doer[K, V] {
-	return doerFunc[K, V](func(m map[K]V) map[K]V {
-		delete(m, k)
-		return m
+	return doerFunc[K, V](func(t *target[K, V]) {
+		t.delete(k)
 	})
 }
 
 // DoDeleteFunc returns the maps.DeleteFunc operation.
 func DoDeleteFunc[K comparable, V any](del func(K, V) bool) Doer[K, V] {
-	return doerFunc[K, V](func(m map[K]V) map[K]V {
-		maps.DeleteFunc(m, del)
-		return m
+	return doerFunc[K, V](func(t *target[K, V]) {
+		var keys []K
+		t.view().Do(func(k K, v V) bool {
+			if del(k, v) {
+				keys = append(keys, k)
+			}
+			return true
+		})
+		for _, k := range keys {
+			t.delete(k)
+		}
+	})
+}
+
+// DoDeleteIf returns the operation that deletes the element associated with k,
+// if it is present and pred reports true for it.
+func DoDeleteIf[K comparable, V any](k K, pred func(V) bool) Doer[K, V] {
+	return doerFunc[K, V](func(t *target[K, V]) {
+		if v, ok := t.index(k); ok && pred(v) {
+			t.delete(k)
+		}
+	})
+}
+
+// DoFunc returns the operation defined by f.
+// f is called with the map being modified by DoAll and must return the modified map.
+// f must not retain its argument, because later operations modify it in place.
+// The operation is always assumed to change the map.
+func DoFunc[K comparable, V any](f func(map[K]V) map[K]V) Doer[K, V] {
+	return doerFunc[K, V](func(t *target[K, V]) {
+		t.m = f(t.writable())
+		t.changed = true
+		t.opaque = true
+	})
+}
+
+// DoGetOrSet returns the operation that sets the element associated with k to v, if not present.
+func DoGetOrSet[K comparable, V any](k K, v V) Doer[K, V] {
+	return DoSetIfAbsent(k, v)
+}
+
+// DoIf returns op if cond is true, otherwise it returns an operation that does nothing.
+func DoIf[K comparable, V any](cond bool, op Doer[K, V]) Doer[K, V] {
+	if !cond {
+		return doerFunc[K, V](func(*target[K, V]) {})
+	}
+	return op
+}
+
+// DoMerge returns the Merge operation.
+func DoMerge[K comparable, V any](src romaps.Map[K, V], resolve func(k K, dv, sv V) V) Doer[K, V] {
+	return doerFunc[K, V](func(t *target[K, V]) {
+		src.Do(func(k K, v V) bool {
+			if dv, ok := t.index(k); ok && resolve != nil {
+				v = resolve(k, dv, v)
+			}
+			t.set(k, v)
+			return true
+		})
+	})
+}
+
+// DoRename returns the Rename operation.
+func DoRename[K comparable, V any](from, to K) Doer[K, V] {
+	return doerFunc[K, V](func(t *target[K, V]) {
+		if v, ok := t.index(from); ok && from != to {
+			t.delete(from)
+			t.set(to, v)
+		}
+	})
+}
+
+// DoSeq returns the operation that does each of ops in order.
+// It counts as a single operation that changed the map if any of ops did.
+func DoSeq[K comparable, V any](ops ...Doer[K, V]) Doer[K, V] {
+	return doerFunc[K, V](func(t *target[K, V]) {
+		var changed bool
+		for _, op := range ops {
+			op.do(t)
+			changed = changed || t.changed
+		}
+		t.changed = changed
+	})
+}
+
+// DoSetIfAbsent returns the operation that sets the element associated with k to v, if not present.
+func DoSetIfAbsent[K comparable, V any](k K, v V) Doer[K, V] {
+	return doerFunc[K, V](func(t *target[K, V]) {
+		if _, ok := t.index(k); !ok {
+			t.set(k, v)
+		}
+	})
+}
+
+// DoSetIfPresent returns the operation that sets the element associated with k to v, if present.
+func DoSetIfPresent[K comparable, V any](k K, v V) Doer[K, V] {
+	return doerFunc[K, V](func(t *target[K, V]) {
+		if _, ok := t.index(k); ok {
+			t.set(k, v)
+		}
 	})
 }
 
 // DoSetIndex returns the set index operation.
 func DoSetIndex[K comparable, V any](k K, v V) Doer[K, V] {
-	return doerFunc[K, V](func(m map[K]V) map[K]V {
-		m[k] = v
-		return m
+	return doerFunc[K, V](func(t *target[K, V]) {
+		t.set(k, v)
+	})
+}
+
+// DoUpdate returns the Update operation.
+func DoUpdate[K comparable, V any](k K, f func(old V, ok bool) (V, bool)) Doer[K, V] {
+	return doerFunc[K, V](func(t *target[K, V]) {
+		old, ok := t.index(k)
+		if v, keep := f(old, ok); keep {
+			t.set(k, v)
+		} else {
+			t.delete(k)
+		}
+	})
+}
+
+// DoWhen returns the operation that does op only if pred reports true
+// for the map as modified by the preceding operations.
+// The map passed to pred is only valid for the duration of the call;
+// pred must not retain it, because later operations modify it in place.
+func DoWhen[K comparable, V any](pred func(romaps.Map[K, V]) bool, op Doer[K, V]) Doer[K, V] {
+	return doerFunc[K, V](func(t *target[K, V]) {
+		if pred(t.view()) {
+			op.do(t)
+		}
 	})
 }
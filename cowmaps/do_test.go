@@ -2,7 +2,12 @@ package cowmaps_test
 
 import (
 	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
 	"github.com/phelmkamp/immut/cowmaps"
+	"github.com/phelmkamp/immut/romaps"
 )
 
 func ExampleDoAll() {
@@ -16,3 +21,129 @@ func ExampleDoAll() {
 	fmt.Println(m)
 	// Output: map[baz:2]
 }
+
+func ExampleDoFunc() {
+	incr := cowmaps.DoFunc(func(m map[string]int) map[string]int {
+		for k := range m {
+			m[k]++
+		}
+		return m
+	})
+	m := cowmaps.CopyOnWrite(map[string]int{"foo": 1})
+	cowmaps.DoAll(&m, 0,
+		cowmaps.DoSetIndex("bar", 2),
+		cowmaps.DoWhen(func(m romaps.Map[string, int]) bool { return m.Len() > 1 }, incr),
+	)
+	fmt.Println(m)
+	// Output: map[bar:3 foo:2]
+}
+
+func TestDoAll_combinators(t *testing.T) {
+	m := cowmaps.CopyOnWrite(map[string]int{"foo": 1})
+	has := func(k string) func(romaps.Map[string, int]) bool {
+		return func(m romaps.Map[string, int]) bool {
+			_, ok := m.Index(k)
+			return ok
+		}
+	}
+	cowmaps.DoAll(&m, 0,
+		cowmaps.DoIf(false, cowmaps.DoSetIndex("a", 1)),
+		cowmaps.DoIf(true, cowmaps.DoSetIndex("b", 2)),
+		cowmaps.DoWhen(has("a"), cowmaps.DoSetIndex("c", 3)),
+		cowmaps.DoWhen(has("b"), cowmaps.DoSeq(cowmaps.DoSetIndex("d", 4), cowmaps.DoDelete[string, int]("foo"))),
+	)
+	if want := romaps.Freeze(map[string]int{"b": 2, "d": 4}); !romaps.Equal(m.RO, want) {
+		t.Errorf("DoAll() = %v, want %v", m, want)
+	}
+}
+
+func TestDoAll_compute(t *testing.T) {
+	m := cowmaps.CopyOnWrite(map[string]int{"foo": 1, "bar": 2})
+	incr := func(old int, ok bool) (int, bool) { return old + 1, true }
+	cowmaps.DoAll(&m, 0,
+		cowmaps.DoUpdate("foo", incr),
+		cowmaps.DoUpdate("baz", incr),
+		cowmaps.DoUpdate("bar", func(int, bool) (int, bool) { return 0, false }),
+		cowmaps.DoGetOrSet("foo", 0),
+		cowmaps.DoGetOrSet("qux", 0),
+		cowmaps.DoMerge(romaps.Freeze(map[string]int{"foo": 3, "a": 1}), func(_ string, dv, sv int) int { return dv + sv }),
+		cowmaps.DoRename[string, int]("qux", "b"),
+		cowmaps.DoRename[string, int]("zzz", "c"),
+	)
+	if want := romaps.Freeze(map[string]int{"foo": 5, "baz": 1, "a": 1, "b": 0}); !romaps.Equal(m.RO, want) {
+		t.Errorf("DoAll() = %v, want %v", m, want)
+	}
+}
+
+func TestDoAll_conditional(t *testing.T) {
+	m := cowmaps.CopyOnWrite(map[string]int{"a": 1, "b": 2, "c": 3})
+	n := cowmaps.DoAll(&m, 0,
+		cowmaps.DoSetIfAbsent("a", 0),                                                 // no-op
+		cowmaps.DoSetIfAbsent("d", 4),                                                 // map[a:1 b:2 c:3 d:4]
+		cowmaps.DoSetIfPresent("e", 5),                                                // no-op
+		cowmaps.DoSetIfPresent("a", 10),                                               // map[a:10 b:2 c:3 d:4]
+		cowmaps.DoDeleteIf("b", func(v int) bool { return v > 2 }),                    // no-op
+		cowmaps.DoDeleteIf("c", func(v int) bool { return v > 2 }),                    // map[a:10 b:2 d:4]
+		cowmaps.DoCompareAndSet("b", 1, 20),                                           // no-op
+		cowmaps.DoCompareAndSet("b", 2, 20),                                           // map[a:10 b:20 d:4]
+		cowmaps.DoCompareAndSet("z", 0, 1),                                            // no-op
+		cowmaps.DoSeq(cowmaps.DoDelete[string, int]("z"), cowmaps.DoSetIndex("d", 4)), // no-op
+	)
+	if want := 4; n != want {
+		t.Errorf("DoAll() = %v, want %v", n, want)
+	}
+	if want := romaps.Freeze(map[string]int{"a": 10, "b": 20, "d": 4}); !romaps.Equal(m.RO, want) {
+		t.Errorf("DoAll() = %v, want %v", m, want)
+	}
+}
+
+func TestDoAll_noop(t *testing.T) {
+	cowmaps.EnableStats(true)
+	defer cowmaps.EnableStats(false)
+	cowmaps.ResetStats()
+	defer cowmaps.ResetStats()
+
+	m := cowmaps.CopyOnWrite(map[string][]int{"a": {1}})
+	n := cowmaps.DoAll(&m, 0,
+		cowmaps.DoDelete[string, []int]("b"),
+		cowmaps.DoCopy(map[string][]int{}),
+		cowmaps.DoDeleteFunc[string, []int](func(string, []int) bool { return false }),
+		cowmaps.DoRename[string, []int]("b", "c"),
+		cowmaps.DoIf(false, cowmaps.DoSetIndex("b", []int{2})),
+	)
+	if want := 0; n != want {
+		t.Errorf("DoAll() = %v, want %v", n, want)
+	}
+	// slices are not comparable, so setting one always takes effect
+	if n = cowmaps.DoAll(&m, 0, cowmaps.DoSetIndex("a", []int{1})); n != 1 {
+		t.Errorf("DoAll() = %v, want %v", n, 1)
+	}
+	want := map[string]cowmaps.OpStats{"DoAll": {Clones: 1, Copied: 1, FastPaths: 1}}
+	if got := cowmaps.Stats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Stats() = %v, want %v", got, want)
+	}
+
+	s := cowmaps.CopyOnWrite(map[string]int{"a": 1})
+	if n = cowmaps.DoAll(&s, 0, cowmaps.DoSetIndex("a", 1), cowmaps.DoMerge(s.RO, nil)); n != 0 {
+		t.Errorf("DoAll() = %v, want %v", n, 0)
+	}
+}
+
+func TestDoAll_identical(t *testing.T) {
+	negZero := math.Copysign(0, -1)
+	m := cowmaps.CopyOnWrite(map[string]float64{"a": 0})
+	if n := cowmaps.DoAll(&m, 0, cowmaps.DoSetIndex("a", 0.0)); n != 0 {
+		t.Errorf("DoAll() = %v, want %v", n, 0)
+	}
+	if n := cowmaps.DoAll(&m, 0, cowmaps.DoSetIndex("a", negZero)); n != 1 {
+		t.Errorf("DoAll() = %v, want %v", n, 1)
+	}
+	if v, _ := m.RO.Index("a"); !math.Signbit(v) {
+		t.Errorf("Index() = %v, want %v", v, negZero)
+	}
+
+	a := cowmaps.CopyOnWrite(map[string]any{"a": 1, "b": []int{1}})
+	if n := cowmaps.DoAll(&a, 0, cowmaps.DoSetIndex[string, any]("a", 1), cowmaps.DoSetIndex[string, any]("b", []int{1})); n != 1 {
+		t.Errorf("DoAll() = %v, want %v", n, 1)
+	}
+}
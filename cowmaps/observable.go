@@ -0,0 +1,257 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package cowmaps
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/phelmkamp/immut/romaps"
+)
+
+// Op is the kind of change described by an Event.
+type Op int
+
+const (
+	OpInsert Op = iota + 1 // a key was added
+	OpUpdate               // the value of a key was changed
+	OpDelete               // a key was removed
+)
+
+// String returns the name of the operation.
+func (op Op) String() string {
+	switch op {
+	case OpInsert:
+		return "insert"
+	case OpUpdate:
+		return "update"
+	case OpDelete:
+		return "delete"
+	}
+	return fmt.Sprintf("Op(%d)", int(op))
+}
+
+// Event describes a change to a single key of an Observable.
+// Old is the zero value for OpInsert and New is the zero value for OpDelete.
+type Event[K comparable, V any] struct {
+	Op  Op
+	Key K
+	Old V
+	New V
+}
+
+// Policy determines what happens when a subscriber's buffer is full.
+type Policy int
+
+const (
+	// Block makes writers wait until the subscriber receives the event.
+	Block Policy = iota
+	// Drop discards the event and counts it (see Subscription.Dropped).
+	Drop
+	// Disconnect closes the subscription, so the subscriber can resynchronize from a new one.
+	Disconnect
+)
+
+// Observable is a copy-on-write map that sends change events to its subscribers.
+// Writes are serialized and events are delivered in the order the writes were made.
+// Writes that do not change the map send no events.
+// It is safe for concurrent use by multiple goroutines.
+type Observable[K comparable, V any] struct {
+	mu   sync.Mutex // serializes writers
+	m    Map[K, V]
+	ro   atomic.Pointer[romaps.Map[K, V]]
+	eq   func(V, V) bool
+	subs map[*Subscription[K, V]]struct{}
+}
+
+// Delete deletes the element with the specified key from the map.
+// If there is no such element, delete is a no-op.
+func (o *Observable[K, V]) Delete(k K) (v V, ok bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if v, ok = o.m.Delete(k); ok {
+		o.publish()
+		o.send(Event[K, V]{Op: OpDelete, Key: k, Old: v})
+	}
+	return
+}
+
+// DoAll does all the supplied operations on the map as a single write
+// and returns the number of operations that changed it (see DoAll).
+// Events are determined from the original values of the keys written by the operations
+// and are sent in an indeterminate order.
+// Note: Operations defined with DoFunc may write any key, so if any is done,
+// events are instead determined by comparing the whole map before and after the operations.
+func (o *Observable[K, V]) DoAll(cap int, ops ...Doer[K, V]) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	t := target[K, V]{cap: cap}
+	if len(o.subs) > 0 {
+		t.prior = make(undoLog[K, V])
+	}
+	n := t.doAll(&o.m, ops)
+	if n == 0 {
+		return 0
+	}
+	o.publish()
+	if len(o.subs) == 0 {
+		return n
+	}
+	after := o.m.RO
+	if t.opaque {
+		o.sendDiff(t.ro, after)
+		return n
+	}
+	for k, p := range t.prior {
+		v, ok := after.Index(k)
+		switch {
+		case !p.ok && ok:
+			o.send(Event[K, V]{Op: OpInsert, Key: k, New: v})
+		case p.ok && !ok:
+			o.send(Event[K, V]{Op: OpDelete, Key: k, Old: p.v})
+		case p.ok && ok && !o.eq(p.v, v):
+			o.send(Event[K, V]{Op: OpUpdate, Key: k, Old: p.v, New: v})
+		}
+	}
+	return n
+}
+
+// Load returns the current version of the map without locking.
+func (o *Observable[K, V]) Load() romaps.Map[K, V] {
+	return *o.ro.Load()
+}
+
+// SetIndex sets the element associated with k to v.
+func (o *Observable[K, V]) SetIndex(k K, v V) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	old, ok := o.m.RO.Index(k)
+	if ok && o.eq(old, v) {
+		return
+	}
+	o.m.SetIndex(k, v)
+	o.publish()
+	if ok {
+		o.send(Event[K, V]{Op: OpUpdate, Key: k, Old: old, New: v})
+	} else {
+		o.send(Event[K, V]{Op: OpInsert, Key: k, New: v})
+	}
+}
+
+// Subscribe returns the current version of the map along with a Subscription
+// that receives an event for every subsequent change.
+// buf is the capacity of the subscription's channel and policy determines what happens when it is full.
+func (o *Observable[K, V]) Subscribe(buf int, policy Policy) (romaps.Map[K, V], *Subscription[K, V]) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	c := make(chan Event[K, V], buf)
+	s := &Subscription[K, V]{C: c, c: c, o: o, policy: policy, done: make(chan struct{})}
+	o.subs[s] = struct{}{}
+	return o.m.RO, s
+}
+
+// publish makes the current map visible to Load.
+func (o *Observable[K, V]) publish() {
+	ro := o.m.RO
+	o.ro.Store(&ro)
+}
+
+// send delivers e to every subscriber according to its policy.
+func (o *Observable[K, V]) send(e Event[K, V]) {
+	for s := range o.subs {
+		switch s.policy {
+		case Drop:
+			select {
+			case s.c <- e:
+			default:
+				s.dropped.Add(1)
+			}
+		case Disconnect:
+			select {
+			case s.c <- e:
+			default:
+				s.dropped.Add(1)
+				o.remove(s)
+			}
+		default:
+			select {
+			case s.c <- e:
+			case <-s.done:
+				o.remove(s)
+			}
+		}
+	}
+}
+
+// sendDiff sends the events that transform before into after.
+func (o *Observable[K, V]) sendDiff(before, after romaps.Map[K, V]) {
+	after.Do(func(k K, v V) bool {
+		if old, ok := before.Index(k); !ok {
+			o.send(Event[K, V]{Op: OpInsert, Key: k, New: v})
+		} else if !o.eq(old, v) {
+			o.send(Event[K, V]{Op: OpUpdate, Key: k, Old: old, New: v})
+		}
+		return true
+	})
+	before.Do(func(k K, v V) bool {
+		if _, ok := after.Index(k); !ok {
+			o.send(Event[K, V]{Op: OpDelete, Key: k, Old: v})
+		}
+		return true
+	})
+}
+
+// remove closes s and stops sending it events.
+func (o *Observable[K, V]) remove(s *Subscription[K, V]) {
+	if _, ok := o.subs[s]; ok {
+		delete(o.subs, s)
+		close(s.c)
+	}
+}
+
+// NewObservable returns an Observable whose initial map is m.
+// Values are compared with == to determine whether a write changed them.
+// m must not be modified afterwards.
+func NewObservable[K, V comparable](m map[K]V) *Observable[K, V] {
+	return NewObservableFunc(m, func(a, b V) bool { return a == b })
+}
+
+// NewObservableFunc is like NewObservable but uses eq to compare values.
+func NewObservableFunc[K comparable, V any](m map[K]V, eq func(V, V) bool) *Observable[K, V] {
+	o := &Observable[K, V]{m: CopyOnWrite(m), eq: eq, subs: make(map[*Subscription[K, V]]struct{})}
+	o.publish()
+	return o
+}
+
+// Subscription receives the change events of an Observable.
+type Subscription[K comparable, V any] struct {
+	// C delivers the events. It is closed when the subscription ends.
+	C <-chan Event[K, V]
+
+	c       chan Event[K, V]
+	o       *Observable[K, V]
+	policy  Policy
+	dropped atomic.Uint64
+	done    chan struct{}
+	once    sync.Once
+}
+
+// Close ends the subscription and closes C.
+// Events already buffered in C may still be received.
+func (s *Subscription[K, V]) Close() {
+	s.once.Do(func() {
+		close(s.done) // unblock a writer waiting to send
+		s.o.mu.Lock()
+		defer s.o.mu.Unlock()
+		s.o.remove(s)
+	})
+}
+
+// Dropped returns the number of events that were not delivered because the buffer was full.
+// With the Disconnect policy, a non-zero result means that the subscription was closed for that reason.
+func (s *Subscription[K, V]) Dropped() uint64 {
+	return s.dropped.Load()
+}
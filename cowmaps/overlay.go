@@ -0,0 +1,185 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package cowmaps
+
+import (
+	"fmt"
+
+	"github.com/phelmkamp/immut/romaps"
+)
+
+// DefaultOverlayRatio is the compaction ratio used by an Overlay created with a ratio of zero or less.
+const DefaultOverlayRatio = 0.1
+
+// Overlay wraps a copy-on-write map whose writes are recorded in delta layers
+// of sets and tombstones over a shared, frozen base map instead of cloning it.
+// Layers are merged as they grow, so reads and writes are O(log d) for d delta entries.
+// Once the delta exceeds the compaction ratio of the base map's size,
+// it is compacted into a fresh base map.
+// Copying an Overlay is O(1) and the copy is unaffected by writes to the original.
+// The zero value is an empty Overlay that uses DefaultOverlayRatio.
+type Overlay[K comparable, V any] struct {
+	base  romaps.Map[K, V]
+	top   *layer[K, V]
+	delta int // number of entries in all layers
+	len   int
+	ratio float64
+}
+
+// layer is a frozen set of writes over the layers beneath it.
+type layer[K comparable, V any] struct {
+	m    map[K]change[V]
+	next *layer[K, V]
+}
+
+// change is a set, or a delete if tomb is true.
+type change[V any] struct {
+	v    V
+	tomb bool
+}
+
+// Compact merges the delta layers into a fresh base map.
+// Note: The base map is cloned before the write-operation is performed.
+func (o *Overlay[K, V]) Compact() {
+	if o.top == nil {
+		return
+	}
+	ro := o.base
+	m2 := clone(ro, o.len)
+	stats.Clone(opOverlay, ro.Len())
+	var layers []*layer[K, V]
+	for l := o.top; l != nil; l = l.next {
+		layers = append(layers, l)
+	}
+	for i := len(layers) - 1; i >= 0; i-- {
+		for k, c := range layers[i].m {
+			if c.tomb {
+				delete(m2, k)
+			} else {
+				m2[k] = c.v
+			}
+		}
+	}
+	o.base, o.top, o.delta = romaps.Freeze(m2), nil, 0
+}
+
+// Delete deletes the element with the specified key from the map.
+// If there is no such element, delete is a no-op.
+// Note: A tombstone is recorded in a new delta layer instead of cloning the base map.
+func (o *Overlay[K, V]) Delete(k K) (v V, ok bool) {
+	// Avoid new layer if key not present.
+	if v, ok = o.Index(k); !ok {
+		stats.FastPath(opOverlay)
+		return
+	}
+	o.push(k, change[V]{tomb: true})
+	o.len--
+	return
+}
+
+// Do calls f on every key/value pair in an indeterminate order, stopping if f returns false.
+func (o Overlay[K, V]) Do(f func(k K, v V) bool) {
+	seen := make(map[K]struct{}, o.delta)
+	for l := o.top; l != nil; l = l.next {
+		for k, c := range l.m {
+			if _, ok := seen[k]; ok {
+				continue
+			}
+			seen[k] = struct{}{}
+			if !c.tomb && !f(k, c.v) {
+				return
+			}
+		}
+	}
+	o.base.Do(func(k K, v V) bool {
+		if _, ok := seen[k]; ok {
+			return true
+		}
+		return f(k, v)
+	})
+}
+
+// Index returns the element associated with k.
+// The boolean value ok is true if the map contains an element with the specified key.
+// The delta layers are checked before the base map.
+func (o Overlay[K, V]) Index(k K) (v V, ok bool) {
+	for l := o.top; l != nil; l = l.next {
+		if c, found := l.m[k]; found {
+			if c.tomb {
+				return v, false
+			}
+			return c.v, true
+		}
+	}
+	return o.base.Index(k)
+}
+
+// Len returns the number of elements in the map.
+func (o Overlay[K, V]) Len() int {
+	return o.len
+}
+
+// RO returns a read-only map of the elements.
+// Note: Unless the delta is empty, the elements are copied into a new map, so it is O(n).
+// Call Compact first to avoid repeated copying.
+func (o Overlay[K, V]) RO() romaps.Map[K, V] {
+	if o.top == nil {
+		return o.base
+	}
+	o.Compact() // o is a copy
+	return o.base
+}
+
+// SetIndex sets the element associated with k to v.
+// Note: The write is recorded in a new delta layer instead of cloning the base map.
+func (o *Overlay[K, V]) SetIndex(k K, v V) {
+	if _, ok := o.Index(k); !ok {
+		o.len++
+	}
+	o.push(k, change[V]{v: v})
+}
+
+// String returns the map formatted as a string.
+func (o Overlay[K, V]) String() string {
+	return fmt.Sprint(o.RO())
+}
+
+// push adds a layer with a single change, merging layers of similar size
+// and compacting if the delta exceeds the ratio.
+func (o *Overlay[K, V]) push(k K, c change[V]) {
+	top := &layer[K, V]{m: map[K]change[V]{k: c}, next: o.top}
+	o.delta++
+	for top.next != nil && len(top.next.m) <= 2*len(top.m) {
+		// Merge into the layer beneath, letting top override it.
+		next := top.next
+		m2 := make(map[K]change[V], len(next.m)+len(top.m))
+		for k, c := range next.m {
+			m2[k] = c
+		}
+		for k, c := range top.m {
+			m2[k] = c
+		}
+		o.delta += len(m2) - len(next.m) - len(top.m)
+		top = &layer[K, V]{m: m2, next: next.next}
+	}
+	o.top = top
+
+	ratio := o.ratio
+	if ratio <= 0 {
+		ratio = DefaultOverlayRatio
+	}
+	if float64(o.delta) > ratio*float64(o.base.Len()) {
+		o.Compact()
+		return
+	}
+	stats.FastPath(opOverlay)
+}
+
+// CopyOnWriteOverlay returns a copy-on-write overlay for the given map.
+// The delta layers are compacted once their size exceeds ratio times the size of the base map;
+// a ratio of zero or less means DefaultOverlayRatio.
+func CopyOnWriteOverlay[K comparable, V any](m map[K]V, ratio float64) Overlay[K, V] {
+	return Overlay[K, V]{base: romaps.Freeze(m), len: len(m), ratio: ratio}
+}
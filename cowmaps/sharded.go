@@ -0,0 +1,150 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package cowmaps
+
+import (
+	"fmt"
+
+	"github.com/phelmkamp/immut/romaps"
+)
+
+// Sharded is a copy-on-write map that splits keys by hash across independent shards,
+// each of which is a Concurrent map. A write clones only the shard of its key.
+// It is safe for concurrent use by multiple goroutines.
+// The zero value is not usable; create a Sharded with NewSharded.
+type Sharded[K comparable, V any] struct {
+	shards []Concurrent[K, V]
+	hash   func(K) uint64
+}
+
+// Delete deletes the element with the specified key from the map.
+// If there is no such element, delete is a no-op.
+func (s *Sharded[K, V]) Delete(k K) (v V, ok bool) {
+	return s.shard(k).Delete(k)
+}
+
+// GetOrSet returns the existing element associated with k, if present.
+// Otherwise, it sets the element associated with k to v and returns v (see GetOrSet).
+func (s *Sharded[K, V]) GetOrSet(k K, v V) (actual V, loaded bool) {
+	return s.shard(k).GetOrSet(k, v)
+}
+
+// Index returns the element associated with k.
+// The boolean value ok is true if the map contains an element with the specified key.
+func (s *Sharded[K, V]) Index(k K) (v V, ok bool) {
+	return s.shard(k).Index(k)
+}
+
+// Len returns the number of elements in the map.
+// Shards are counted one at a time, so the result may not reflect a single point in time;
+// use Snapshot for a consistent count.
+func (s *Sharded[K, V]) Len() int {
+	var n int
+	for i := range s.shards {
+		n += s.shards[i].Len()
+	}
+	return n
+}
+
+// SetIndex sets the element associated with k to v.
+// Note: Only the shard of k is cloned before the write-operation is performed.
+func (s *Sharded[K, V]) SetIndex(k K, v V) {
+	s.shard(k).SetIndex(k, v)
+}
+
+// Snapshot returns a consistent read-only view of all shards at a single point in time.
+// Writers are blocked while the shards are loaded.
+func (s *Sharded[K, V]) Snapshot() ShardedSnapshot[K, V] {
+	for i := range s.shards {
+		s.shards[i].mu.Lock()
+	}
+	snap := ShardedSnapshot[K, V]{shards: make([]romaps.Map[K, V], len(s.shards)), hash: s.hash}
+	for i := range s.shards {
+		snap.shards[i] = s.shards[i].Load()
+	}
+	for i := range s.shards {
+		s.shards[i].mu.Unlock()
+	}
+	return snap
+}
+
+// String returns a snapshot of the map formatted as a string.
+func (s *Sharded[K, V]) String() string {
+	return s.Snapshot().String()
+}
+
+// Update sets the element associated with k to the result of calling f
+// with the existing element and whether it is present (see Update).
+// f is called while holding the write lock of k's shard, so it must not write to s.
+func (s *Sharded[K, V]) Update(k K, f func(old V, ok bool) (V, bool)) {
+	s.shard(k).Update(k, f)
+}
+
+func (s *Sharded[K, V]) shard(k K) *Concurrent[K, V] {
+	return &s.shards[s.hash(k)%uint64(len(s.shards))]
+}
+
+// NewSharded returns an empty Sharded with n shards that uses hash to assign keys to shards.
+// Keys that are equal must have equal hashes.
+// NewSharded panics if n is less than 1.
+func NewSharded[K comparable, V any](n int, hash func(K) uint64) *Sharded[K, V] {
+	if n < 1 {
+		panic("cowmaps: number of shards must be positive")
+	}
+	return &Sharded[K, V]{shards: make([]Concurrent[K, V], n), hash: hash}
+}
+
+// ShardedSnapshot is a read-only view of a Sharded map at a single point in time.
+type ShardedSnapshot[K comparable, V any] struct {
+	shards []romaps.Map[K, V]
+	hash   func(K) uint64
+}
+
+// Do calls f on every key/value pair in an indeterminate order, stopping if f returns false.
+func (s ShardedSnapshot[K, V]) Do(f func(k K, v V) bool) {
+	ok := true
+	for _, m := range s.shards {
+		m.Do(func(k K, v V) bool {
+			ok = f(k, v)
+			return ok
+		})
+		if !ok {
+			return
+		}
+	}
+}
+
+// Index returns the element associated with k.
+// The boolean value ok is true if the map contains an element with the specified key.
+func (s ShardedSnapshot[K, V]) Index(k K) (v V, ok bool) {
+	if len(s.shards) == 0 {
+		return
+	}
+	return s.shards[s.hash(k)%uint64(len(s.shards))].Index(k)
+}
+
+// Len returns the number of elements in the map.
+func (s ShardedSnapshot[K, V]) Len() int {
+	var n int
+	for _, m := range s.shards {
+		n += m.Len()
+	}
+	return n
+}
+
+// RO returns a read-only map of the elements for use with the romaps functions.
+// Note: The elements of all shards are copied into a new map, so it is O(n).
+func (s ShardedSnapshot[K, V]) RO() romaps.Map[K, V] {
+	m2 := make(map[K]V, s.Len())
+	for _, m := range s.shards {
+		romaps.Copy(m2, m)
+	}
+	return romaps.Freeze(m2)
+}
+
+// String returns the map formatted as a string.
+func (s ShardedSnapshot[K, V]) String() string {
+	return fmt.Sprint(s.RO())
+}
@@ -0,0 +1,74 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package cowmaps
+
+import (
+	"github.com/phelmkamp/immut/internal/cowstats"
+)
+
+const (
+	opClear = iota
+	opCopy
+	opDelete
+	opDeleteFunc
+	opDoAll
+	opGetOrSet
+	opMerge
+	opOverlay
+	opRename
+	opSetIndex
+	opTransient
+	opUpdate
+	opVersioned
+	numOps
+)
+
+var opNames = [numOps]string{
+	opClear:      "Clear",
+	opCopy:       "Copy",
+	opDelete:     "Delete",
+	opDeleteFunc: "DeleteFunc",
+	opDoAll:      "DoAll",
+	opGetOrSet:   "GetOrSet",
+	opMerge:      "Merge",
+	opOverlay:    "Overlay",
+	opRename:     "Rename",
+	opSetIndex:   "SetIndex",
+	opTransient:  "Transient",
+	opUpdate:     "Update",
+	opVersioned:  "Versioned",
+}
+
+var stats = cowstats.New("cowmaps", opNames[:]...)
+
+// OpStats holds the clone counters for one operation.
+type OpStats struct {
+	Clones    int64 // number of times the underlying map was cloned
+	Copied    int64 // number of entries copied into clones
+	FastPaths int64 // number of writes that avoided a clone
+}
+
+// EnableStats enables or disables counting clones.
+// Counting is disabled by default.
+// Once enabled, the counters are also published via expvar as "cowmaps".
+func EnableStats(enabled bool) {
+	stats.Enable(enabled)
+}
+
+// ResetStats sets all clone counters to zero.
+func ResetStats() {
+	stats.Reset()
+}
+
+// Stats returns the current clone counters keyed by operation name.
+// Operations that have not been counted are omitted.
+func Stats() map[string]OpStats {
+	snap := stats.Snapshot()
+	m := make(map[string]OpStats, len(snap))
+	for op, st := range snap {
+		m[op] = OpStats(st)
+	}
+	return m
+}
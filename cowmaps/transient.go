@@ -0,0 +1,74 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package cowmaps
+
+// TransientMap is a private, mutable copy of a Map for bulk mutation.
+// Writes are performed in place until Persistent is called, after which any use panics.
+// A TransientMap is not safe for concurrent use by multiple goroutines.
+type TransientMap[K comparable, V any] struct {
+	m    map[K]V
+	done bool
+}
+
+// Delete deletes the element with the specified key from the map.
+// If there is no such element, delete is a no-op.
+func (t *TransientMap[K, V]) Delete(k K) {
+	t.check()
+	delete(t.m, k)
+}
+
+// Do does the supplied operations on the map in place.
+func (t *TransientMap[K, V]) Do(ops ...Doer[K, V]) {
+	t.check()
+	tg := target[K, V]{m: t.m}
+	for _, op := range ops {
+		op.do(&tg)
+	}
+	t.m = tg.m
+}
+
+// Index returns the element associated with k.
+// The boolean value ok is true if the map contains an element with the specified key.
+func (t *TransientMap[K, V]) Index(k K) (v V, ok bool) {
+	t.check()
+	v, ok = t.m[k]
+	return
+}
+
+// Len returns the number of elements in the map.
+func (t *TransientMap[K, V]) Len() int {
+	t.check()
+	return len(t.m)
+}
+
+// Persistent returns the map as a Map without copying it.
+// The TransientMap must not be used afterwards.
+func (t *TransientMap[K, V]) Persistent() Map[K, V] {
+	t.check()
+	t.done = true
+	m := CopyOnWrite(t.m)
+	t.m = nil
+	return m
+}
+
+// SetIndex sets the element associated with k to v.
+func (t *TransientMap[K, V]) SetIndex(k K, v V) {
+	t.check()
+	t.m[k] = v
+}
+
+func (t *TransientMap[K, V]) check() {
+	if t.done {
+		panic("cowmaps: use of TransientMap after Persistent")
+	}
+}
+
+// Transient returns a TransientMap initialized with a private copy of m.
+// Note: The underlying map is cloned once, up front.
+func Transient[K comparable, V any](m Map[K, V]) *TransientMap[K, V] {
+	ro := m.RO
+	stats.Clone(opTransient, ro.Len())
+	return &TransientMap[K, V]{m: clone(ro, ro.Len())}
+}
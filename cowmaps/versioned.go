@@ -0,0 +1,161 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package cowmaps
+
+import (
+	"sync"
+
+	"github.com/phelmkamp/immut/romaps"
+)
+
+// Versioned is a copy-on-write map that assigns a monotonically increasing version
+// to each committed batch of writes and can reconstruct the map as of a previous version.
+// Only the latest map is kept in full; previous versions are reconstructed from per-version undo logs
+// that record the prior values of the keys written by each batch.
+// It is safe for concurrent use by multiple goroutines.
+type Versioned[K comparable, V any] struct {
+	mu      sync.RWMutex
+	latest  romaps.Map[K, V]
+	version uint64
+	undo    []undoLog[K, V] // undo[i] reverts version-len(undo)+i+1 to the version before it
+	retain  int
+}
+
+// prior is the value of a key before a batch, with ok false if it was not present.
+type prior[V any] struct {
+	v  V
+	ok bool
+}
+
+type undoLog[K comparable, V any] map[K]prior[V]
+
+// AsOf returns the map as of the given version.
+// The boolean value ok is false if the version has not been committed or is no longer retained.
+// Note: Unless version is the latest, the map is reconstructed in a new map, so it is O(n).
+func (m *Versioned[K, V]) AsOf(version uint64) (ro romaps.Map[K, V], ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	oldest := m.version - uint64(len(m.undo))
+	if version > m.version || version < oldest {
+		return ro, false
+	}
+	if version == m.version {
+		return m.latest, true
+	}
+	m2 := clone(m.latest, m.latest.Len())
+	stats.Clone(opVersioned, len(m2))
+	for i := len(m.undo) - 1; i >= int(version-oldest); i-- {
+		for k, p := range m.undo[i] {
+			if p.ok {
+				m2[k] = p.v
+			} else {
+				delete(m2, k)
+			}
+		}
+	}
+	return romaps.Freeze(m2), true
+}
+
+// Latest returns the map as of the latest version, along with that version.
+func (m *Versioned[K, V]) Latest() (romaps.Map[K, V], uint64) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.latest, m.version
+}
+
+// Oldest returns the oldest version that is still retained.
+func (m *Versioned[K, V]) Oldest() uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.version - uint64(len(m.undo))
+}
+
+// Update calls f with a Batch and commits its writes as a new version, which is returned.
+// The Batch must not be used after f returns.
+// If f does not write anything, no version is created and the latest version is returned.
+// f is called while holding the write lock, so it must not write to m.
+// Note: The underlying map is cloned once per batch before the write-operations are performed.
+func (m *Versioned[K, V]) Update(f func(b *Batch[K, V])) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b := &Batch[K, V]{base: m.latest}
+	f(b)
+	b.done = true
+	// Avoid new version if nothing was written.
+	if b.m == nil {
+		stats.FastPath(opVersioned)
+		return m.version
+	}
+	m.latest = romaps.Freeze(b.m)
+	m.version++
+	m.undo = append(m.undo, b.undo)
+	if m.retain >= 0 && len(m.undo) > m.retain {
+		// Garbage collect versions that are no longer retained.
+		n := copy(m.undo, m.undo[len(m.undo)-m.retain:])
+		for i := n; i < len(m.undo); i++ {
+			m.undo[i] = nil
+		}
+		m.undo = m.undo[:n]
+	}
+	return m.version
+}
+
+// NewVersioned returns a Versioned whose initial map, version 0, is m.
+// retain is the number of previous versions available to AsOf; if it is negative, all versions are retained.
+// m must not be modified afterwards.
+func NewVersioned[K comparable, V any](m map[K]V, retain int) *Versioned[K, V] {
+	return &Versioned[K, V]{latest: romaps.Freeze(m), retain: retain}
+}
+
+// Batch records writes to be committed together as one version of a Versioned map.
+type Batch[K comparable, V any] struct {
+	base romaps.Map[K, V]
+	m    map[K]V // private clone of base, once written
+	undo undoLog[K, V]
+	done bool
+}
+
+// Delete deletes the element with the specified key from the map.
+// If there is no such element, delete is a no-op.
+func (b *Batch[K, V]) Delete(k K) {
+	// Avoid clone if key not present.
+	if _, ok := b.Index(k); !ok {
+		return
+	}
+	b.record(k)
+	delete(b.m, k)
+}
+
+// Index returns the element associated with k, including writes made earlier in the batch.
+// The boolean value ok is true if the map contains an element with the specified key.
+func (b *Batch[K, V]) Index(k K) (v V, ok bool) {
+	if b.m != nil {
+		v, ok = b.m[k]
+		return
+	}
+	return b.base.Index(k)
+}
+
+// SetIndex sets the element associated with k to v.
+func (b *Batch[K, V]) SetIndex(k K, v V) {
+	b.record(k)
+	b.m[k] = v
+}
+
+// record clones the base map on the first write and saves the prior value of k.
+func (b *Batch[K, V]) record(k K) {
+	if b.done {
+		panic("cowmaps: use of Batch after Update returned")
+	}
+	if b.m == nil {
+		b.m = clone(b.base, b.base.Len()+1)
+		stats.Clone(opVersioned, len(b.m))
+		b.undo = make(undoLog[K, V])
+	}
+	if _, ok := b.undo[k]; !ok {
+		v, ok := b.m[k]
+		b.undo[k] = prior[V]{v: v, ok: ok}
+	}
+}
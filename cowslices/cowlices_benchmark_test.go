@@ -6,6 +6,7 @@ package cowslices
 
 import (
 	"golang.org/x/exp/slices"
+	"math/rand"
 	"testing"
 )
 
@@ -52,3 +53,48 @@ func BenchmarkSlicesInsert(b *testing.B) {
 		slices.Insert(ints, len(ints)-1, len(ints))
 	}
 }
+
+func BenchmarkSetIndex(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		ints := CopyOnWrite(fill(1, 1_000))
+		b.StartTimer()
+		for j := 0; j < ints.RO.Len(); j++ {
+			ints.SetIndex(j, j)
+		}
+	}
+}
+
+func BenchmarkSetIndex_transient(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		ints := CopyOnWrite(fill(1, 1_000))
+		b.StartTimer()
+		t := Transient(ints)
+		for j := 0; j < ints.RO.Len(); j++ {
+			t.SetIndex(j, j)
+		}
+		ints = t.Persistent()
+	}
+}
+
+func BenchmarkSortFunc(b *testing.B) {
+	benchmarkSortFunc(b, 0)
+}
+
+func BenchmarkSortFunc_parallel(b *testing.B) {
+	benchmarkSortFunc(b, 1<<12)
+}
+
+func benchmarkSortFunc(b *testing.B, threshold int) {
+	defer SetParallelSortThreshold(SetParallelSortThreshold(threshold))
+	ints := make([]int, 1_000_000)
+	for i := range ints {
+		ints[i] = rand.Int()
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s := CopyOnWrite(ints)
+		SortFunc(&s, func(a, b int) int { return a - b })
+	}
+}
@@ -6,6 +6,7 @@ package cowslices
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/phelmkamp/immut/roslices"
 	"golang.org/x/exp/constraints"
@@ -19,11 +20,13 @@ type Slice[E any] struct {
 
 // SetIndex sets the element at i to v.
 // Note: The underlying slice is cloned before the write-operation is performed.
+// To set many elements without cloning each time, use Transient.
 func (s *Slice[E]) SetIndex(i int, v E) {
 	ro := s.RO
 	s2 := clone(ro, ro.Len()+1)
+	stats.Clone(opSetIndex, ro.Len())
 	s2[i] = v
-	s.RO = roslices.Freeze(s2)
+	s.set(s2)
 }
 
 // String returns the underlying slice formatted as a string.
@@ -36,16 +39,35 @@ func CopyOnWrite[E any](s []E) Slice[E] {
 	return Slice[E]{RO: roslices.Freeze(s)}
 }
 
+// Append appends the values v... to the end of s, returning the modified slice.
+// Note: The underlying slice is cloned before the write-operation is performed.
+func Append[E any](s Slice[E], v ...E) Slice[E] {
+	// Avoid clone if nothing to append.
+	if len(v) == 0 {
+		stats.FastPath(opAppend)
+		return s
+	}
+	// Reallocate just once with enough capacity.
+	ro := s.RO
+	s2 := clone(ro, ro.Len()+len(v))
+	stats.Clone(opAppend, ro.Len())
+	s2 = append(s2, v...)
+	s.set(s2)
+	return s
+}
+
 // Clip removes unused capacity from the slice, returning s[:len(s):len(s)].
 // Note: The underlying slice is cloned before the write-operation is performed.
 func Clip[E any](s Slice[E]) Slice[E] {
 	// Avoid clone if no unused capacity to remove.
 	if ro := s.RO; ro.Cap() == ro.Len() {
+		stats.FastPath(opClip)
 		return s
 	}
 	s2 := roslices.Clone(s.RO)
+	stats.Clone(opClip, len(s2))
 	s2 = slices.Clip(s2)
-	s.RO = roslices.Freeze(s2)
+	s.set(s2)
 	return s
 }
 
@@ -55,11 +77,13 @@ func Clip[E any](s Slice[E]) Slice[E] {
 func Compact[E comparable](s Slice[E]) Slice[E] {
 	// Avoid clone if already compact.
 	if isCompact(s.RO) {
+		stats.FastPath(opCompact)
 		return s
 	}
 	s2 := roslices.Clone(s.RO)
+	stats.Clone(opCompact, len(s2))
 	s2 = slices.Compact(s2)
-	s.RO = roslices.Freeze(s2)
+	s.set(s2)
 	return s
 }
 
@@ -68,11 +92,40 @@ func Compact[E comparable](s Slice[E]) Slice[E] {
 func CompactFunc[E any](s Slice[E], eq func(E, E) bool) Slice[E] {
 	// Avoid clone if already compact.
 	if isCompactFunc(s.RO, eq) {
+		stats.FastPath(opCompactFunc)
 		return s
 	}
 	s2 := roslices.Clone(s.RO)
+	stats.Clone(opCompactFunc, len(s2))
 	s2 = slices.CompactFunc(s2, eq)
-	s.RO = roslices.Freeze(s2)
+	s.set(s2)
+	return s
+}
+
+// Concat returns a new slice concatenating the passed in slices.
+// Note: The slices are copied into a new underlying slice,
+// unless at most one of them is non-empty.
+func Concat[E any](ss ...Slice[E]) Slice[E] {
+	// Avoid clone if at most one slice is non-empty.
+	var size, nonEmpty int
+	var s Slice[E]
+	for _, s2 := range ss {
+		if n := s2.RO.Len(); n > 0 {
+			size += n
+			nonEmpty++
+			s = s2
+		}
+	}
+	if nonEmpty < 2 {
+		stats.FastPath(opConcat)
+		return s
+	}
+	s2 := make([]E, 0, size)
+	stats.Clone(opConcat, size)
+	for _, s3 := range ss {
+		s2 = appendRO(s2, s3.RO)
+	}
+	s.set(s2)
 	return s
 }
 
@@ -83,11 +136,44 @@ func CompactFunc[E any](s Slice[E], eq func(E, E) bool) Slice[E] {
 // Note: The underlying slice is cloned before the write-operation is performed.
 func Delete[E any](s Slice[E], i, j int) Slice[E] {
 	s2 := roslices.Clone(s.RO)
+	stats.Clone(opDelete, len(s2))
 	s2 = slices.Delete(s2, i, j)
-	s.RO = roslices.Freeze(s2)
+	s.set(s2)
+	return s
+}
+
+// DeleteFunc removes any elements from s for which del returns true,
+// returning the modified slice.
+// Note: The underlying slice is cloned before the write-operation is performed.
+func DeleteFunc[E any](s Slice[E], del func(E) bool) Slice[E] {
+	// Avoid clone if no element to delete.
+	if roslices.IndexFunc(s.RO, del) < 0 {
+		stats.FastPath(opDeleteFunc)
+		return s
+	}
+	s2 := roslices.Clone(s.RO)
+	stats.Clone(opDeleteFunc, len(s2))
+	s2 = deleteFunc(s2, del)
+	s.set(s2)
 	return s
 }
 
+// Fill sets every element of x to v.
+// Note: The underlying slice is cloned before the write-operation is performed.
+func Fill[E any](x *Slice[E], v E) {
+	// Avoid clone if empty.
+	ro := x.RO
+	if ro.Len() < 1 {
+		stats.FastPath(opFill)
+		return
+	}
+	// No need to clone just to overwrite.
+	s2 := make([]E, ro.Len())
+	stats.Clone(opFill, 0)
+	setAll(s2, v)
+	x.set(s2)
+}
+
 // Grow increases the slice's capacity, if necessary, to guarantee space for
 // another n elements. After Grow(n), at least n elements can be appended
 // to the slice without another allocation. Grow may modify elements of the
@@ -99,11 +185,13 @@ func Grow[E any](s Slice[E], n int) Slice[E] {
 	ro := s.RO
 	cap2 := ro.Len() + n
 	if ro.Cap() >= cap2 {
+		stats.FastPath(opGrow)
 		return s
 	}
 	// Reallocate just once with enough capacity.
 	s2 := clone(ro, cap2)
-	s.RO = roslices.Freeze(s2)
+	stats.Clone(opGrow, ro.Len())
+	s.set(s2)
 	return s
 }
 
@@ -120,47 +208,143 @@ func Insert[E any](s Slice[E], i int, v ...E) Slice[E] {
 		cap2 = tot
 	}
 	s2 := clone(ro, cap2)
+	stats.Clone(opInsert, ro.Len())
 	s2 = slices.Insert(s2, i, v...)
-	s.RO = roslices.Freeze(s2)
+	s.set(s2)
+	return s
+}
+
+// Repeat returns a new slice that repeats s the given number of times.
+// Repeat panics if count is negative or if the result of (len(s) * count) overflows.
+// Note: The slice is copied into a new underlying slice, unless count is 1.
+func Repeat[E any](s Slice[E], count int) Slice[E] {
+	checkRepeat(s.RO.Len(), count)
+	// Avoid clone if repeated once.
+	if count == 1 {
+		stats.FastPath(opRepeat)
+		return s
+	}
+	s2 := repeat(s.RO, count)
+	stats.Clone(opRepeat, len(s2))
+	s.set(s2)
 	return s
 }
 
+// Replace replaces the elements s[i:j] by the given v, and returns the
+// modified slice. Replace panics if s[i:j] is not a valid slice of s.
+// Note: The underlying slice is cloned before the write-operation is performed.
+func Replace[E any](s Slice[E], i, j int, v ...E) Slice[E] {
+	ro := s.RO
+	checkSlice(i, j, ro.Len())
+	// Avoid clone if nothing to replace.
+	if i == j && len(v) == 0 {
+		stats.FastPath(opReplace)
+		return s
+	}
+	// Reallocate just once with enough capacity.
+	s2 := make([]E, 0, ro.Len()-(j-i)+len(v))
+	stats.Clone(opReplace, ro.Len()-(j-i))
+	s2 = appendRO(s2, ro.Slice(0, i))
+	s2 = append(s2, v...)
+	s2 = appendRO(s2, ro.Slice(j, ro.Len()))
+	s.set(s2)
+	return s
+}
+
+// Reverse reverses the elements of x in place.
+// Note: The underlying slice is cloned before the write-operation is performed.
+func Reverse[E any](x *Slice[E]) {
+	// Avoid clone if reversal has no effect.
+	if x.RO.Len() < 2 {
+		stats.FastPath(opReverse)
+		return
+	}
+	s2 := roslices.Clone(x.RO)
+	stats.Clone(opReverse, len(s2))
+	reverse(s2)
+	x.set(s2)
+}
+
+// SetRange sets the elements x[i:i+len(v)] to v.
+// SetRange panics if x[i:i+len(v)] is not a valid slice of x.
+// Note: The underlying slice is cloned before the write-operation is performed.
+func SetRange[E any](x *Slice[E], i int, v ...E) {
+	ro := x.RO
+	checkSlice(i, i+len(v), ro.Len())
+	// Avoid clone if nothing to set.
+	if len(v) == 0 {
+		stats.FastPath(opSetRange)
+		return
+	}
+	s2 := roslices.Clone(ro)
+	stats.Clone(opSetRange, len(s2))
+	copy(s2[i:], v)
+	x.set(s2)
+}
+
 // Sort sorts a slice of any ordered type in ascending order.
+// Large slices are sorted in parallel (see SetParallelSortThreshold).
 // Note: The underlying slice is cloned before the write-operation is performed.
 func Sort[E constraints.Ordered](x *Slice[E]) {
 	// Avoid clone if already sorted.
 	if roslices.IsSorted(x.RO) {
+		stats.FastPath(opSort)
 		return
 	}
 	s2 := roslices.Clone(x.RO)
-	slices.Sort(s2)
-	x.RO = roslices.Freeze(s2)
+	stats.Clone(opSort, len(s2))
+	sortOrdered(s2)
+	x.set(s2)
 }
 
-// SortFunc sorts the slice x in ascending order as determined by the less function.
-// This sort is not guaranteed to be stable.
+// SortFunc sorts the slice x in ascending order as determined by the cmp
+// function. This sort is not guaranteed to be stable.
+// cmp(a, b) should return a negative number when a < b, a positive number when
+// a > b and zero when a == b.
+// Large slices are sorted in parallel (see SetParallelSortThreshold).
 // Note: The underlying slice is cloned before the write-operation is performed.
-func SortFunc[E any](x *Slice[E], less func(a, b E) bool) {
+func SortFunc[E any](x *Slice[E], cmp func(a, b E) int) {
 	// Avoid clone if already sorted.
-	if roslices.IsSortedFunc(x.RO, less) {
+	if isSortedFunc(x.RO, cmp) {
+		stats.FastPath(opSortFunc)
 		return
 	}
 	s2 := roslices.Clone(x.RO)
-	slices.SortFunc(s2, less)
-	x.RO = roslices.Freeze(s2)
+	stats.Clone(opSortFunc, len(s2))
+	sortLessFunc(s2, lessFunc(cmp), false)
+	x.set(s2)
 }
 
 // SortStableFunc sorts the slice x while keeping the original order of equal
-// elements, using less to compare elements.
+// elements, using cmp to compare elements in the same way as SortFunc.
+// Large slices are sorted in parallel (see SetParallelSortThreshold).
 // Note: The underlying slice is cloned before the write-operation is performed.
-func SortStableFunc[E any](x *Slice[E], less func(a, b E) bool) {
+func SortStableFunc[E any](x *Slice[E], cmp func(a, b E) int) {
 	// Avoid clone if already sorted.
-	if roslices.IsSortedFunc(x.RO, less) {
+	if isSortedFunc(x.RO, cmp) {
+		stats.FastPath(opSortStableFunc)
 		return
 	}
 	s2 := roslices.Clone(x.RO)
-	slices.SortStableFunc(s2, less)
-	x.RO = roslices.Freeze(s2)
+	stats.Clone(opSortStableFunc, len(s2))
+	sortLessFunc(s2, lessFunc(cmp), true)
+	x.set(s2)
+}
+
+// Swap swaps the elements at i and j.
+// Note: The underlying slice is cloned before the write-operation is performed.
+func Swap[E any](x *Slice[E], i, j int) {
+	ro := x.RO
+	_, _ = ro.Index(i), ro.Index(j) // bounds check
+	// Avoid clone if swap has no effect.
+	if i == j {
+		stats.FastPath(opSwap)
+		return
+	}
+	s2 := roslices.Clone(ro)
+	stats.Clone(opSwap, len(s2))
+	s2[i], s2[j] = s2[j], s2[i]
+	x.set(s2)
 }
 
 func isCompact[E comparable](s roslices.Slice[E]) bool {
@@ -181,6 +365,87 @@ func isCompactFunc[E any](s roslices.Slice[E], eq func(E, E) bool) bool {
 	return true
 }
 
+func isSortedFunc[E any](s roslices.Slice[E], cmp func(a, b E) int) bool {
+	for i := s.Len() - 1; i > 0; i-- {
+		if cmp(s.Index(i), s.Index(i-1)) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// lessFunc adapts cmp to the less function expected by slices.SortFunc.
+func lessFunc[E any](cmp func(a, b E) int) func(a, b E) bool {
+	return func(a, b E) bool {
+		return cmp(a, b) < 0
+	}
+}
+
+func appendRO[E any](s []E, ro roslices.Slice[E]) []E {
+	n := len(s)
+	s = s[:n+ro.Len()]
+	roslices.Copy(s[n:], ro)
+	return s
+}
+
+func checkSlice(i, j, n int) {
+	if i < 0 || j > n || i > j {
+		panic(fmt.Sprintf("slice bounds out of range [%d:%d] with length %d", i, j, n))
+	}
+}
+
+func checkRepeat(n, count int) {
+	if count < 0 {
+		panic("cannot be negative")
+	}
+	if n > 0 && count > math.MaxInt/n {
+		panic("the result of (len(s) * count) overflows")
+	}
+}
+
+func deleteFunc[E any](s []E, del func(E) bool) []E {
+	i := 0
+	for _, v := range s {
+		if !del(v) {
+			s[i] = v
+			i++
+		}
+	}
+	var zero E
+	for j := i; j < len(s); j++ {
+		s[j] = zero // avoid memory leak
+	}
+	return s[:i]
+}
+
+func setAll[E any](s []E, v E) {
+	for i := range s {
+		s[i] = v
+	}
+}
+
+func repeat[E any](ro roslices.Slice[E], count int) []E {
+	s2 := make([]E, 0, ro.Len()*count)
+	if ro.Len() == 0 {
+		return s2
+	}
+	for i := 0; i < count; i++ {
+		s2 = appendRO(s2, ro)
+	}
+	return s2
+}
+
+func reverse[E any](s []E) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
+
+// set sets the underlying slice to s2, which must be a private copy.
+func (s *Slice[E]) set(s2 []E) {
+	s.RO = roslices.Freeze(s2)
+}
+
 func clone[E any](ro roslices.Slice[E], cap int) []E {
 	s2 := make([]E, ro.Len(), cap)
 	roslices.Copy(s2, ro)
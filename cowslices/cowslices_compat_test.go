@@ -33,7 +33,7 @@ func TestSortIntSlice(t *testing.T) {
 
 func TestSortFuncIntSlice(t *testing.T) {
 	data := CopyOnWrite(ints[:])
-	SortFunc(&data, func(a, b int) bool { return a < b })
+	SortFunc(&data, func(a, b int) int { return a - b })
 	if !roslices.IsSorted(data.RO) {
 		t.Errorf("sorted %v", ints)
 		t.Errorf("   got %v", data)
@@ -108,6 +108,10 @@ func intPairLess(x, y intPair) bool {
 	return x.a < y.a
 }
 
+func intPairCmp(x, y intPair) int {
+	return x.a - y.a
+}
+
 // Record initial order in B.
 func (d intPairs) initB() {
 	for i := range d {
@@ -148,7 +152,7 @@ func TestStability(t *testing.T) {
 		t.Fatalf("terrible rand.rand")
 	}
 	d.initB()
-	SortStableFunc(&data, intPairLess)
+	SortStableFunc(&data, intPairCmp)
 	if !roslices.IsSortedFunc(data.RO, intPairLess) {
 		t.Errorf("Stable didn't sort %d ints", n)
 	}
@@ -159,7 +163,7 @@ func TestStability(t *testing.T) {
 
 	// already sorted
 	d.initB()
-	SortStableFunc(&data, intPairLess)
+	SortStableFunc(&data, intPairCmp)
 	if !roslices.IsSortedFunc(data.RO, intPairLess) {
 		t.Errorf("Stable shuffled sorted %d ints (order)", n)
 	}
@@ -173,7 +177,7 @@ func TestStability(t *testing.T) {
 		d[i].a = len(d) - i
 	}
 	d.initB()
-	SortStableFunc(&data, intPairLess)
+	SortStableFunc(&data, intPairCmp)
 	if !roslices.IsSortedFunc(data.RO, intPairLess) {
 		t.Errorf("Stable didn't sort %d ints", n)
 	}
@@ -329,6 +333,61 @@ func TestCompactFunc(t *testing.T) {
 	}
 }
 
+var deleteFuncTests = []struct {
+	s    Slice[int]
+	fn   func(int) bool
+	want Slice[int]
+}{
+	{
+		CopyOnWrite[int](nil),
+		func(int) bool { return true },
+		CopyOnWrite[int](nil),
+	},
+	{
+		CopyOnWrite([]int{1, 2, 3}),
+		func(int) bool { return true },
+		CopyOnWrite[int](nil),
+	},
+	{
+		CopyOnWrite([]int{1, 2, 3}),
+		func(int) bool { return false },
+		CopyOnWrite([]int{1, 2, 3}),
+	},
+	{
+		CopyOnWrite([]int{1, 2, 3}),
+		func(i int) bool { return i > 2 },
+		CopyOnWrite([]int{1, 2}),
+	},
+	{
+		CopyOnWrite([]int{1, 2, 3}),
+		func(i int) bool { return i < 2 },
+		CopyOnWrite([]int{2, 3}),
+	},
+	{
+		CopyOnWrite([]int{10, 2, 30}),
+		func(i int) bool { return i >= 10 },
+		CopyOnWrite([]int{2}),
+	},
+}
+
+func TestDeleteFunc(t *testing.T) {
+	for i, test := range deleteFuncTests {
+		if got := DeleteFunc(test.s, test.fn); !roslices.Equal(got.RO, test.want.RO) {
+			t.Errorf("DeleteFunc case %d: got %v, want %v", i, got, test.want)
+		}
+	}
+}
+
+func panics(f func()) (b bool) {
+	defer func() {
+		if x := recover(); x != nil {
+			b = true
+		}
+	}()
+	f()
+	return false
+}
+
 func TestGrow(t *testing.T) {
 	s1 := CopyOnWrite([]int{1, 2, 3})
 	s2 := Grow(s1, 1000)
@@ -358,6 +417,191 @@ func TestClip(t *testing.T) {
 	}
 }
 
+func TestReverse(t *testing.T) {
+	even := CopyOnWrite([]int{3, 1, 4, 1, 5, 9}) // len = 6
+	Reverse(&even)
+	if want := roslices.Freeze([]int{9, 5, 1, 4, 1, 3}); !roslices.Equal(even.RO, want) {
+		t.Errorf("Reverse(even) = %v, want %v", even, want)
+	}
+
+	odd := CopyOnWrite([]int{3, 1, 4, 1, 5, 9, 2}) // len = 7
+	Reverse(&odd)
+	if want := roslices.Freeze([]int{2, 9, 5, 1, 4, 1, 3}); !roslices.Equal(odd.RO, want) {
+		t.Errorf("Reverse(odd) = %v, want %v", odd, want)
+	}
+
+	words := CopyOnWrite(strings.Fields("one two three"))
+	Reverse(&words)
+	if want := roslices.Freeze(strings.Fields("three two one")); !roslices.Equal(words.RO, want) {
+		t.Errorf("Reverse(words) = %v, want %v", words, want)
+	}
+
+	singleton := CopyOnWrite([]string{"one"})
+	Reverse(&singleton)
+	if want := roslices.Freeze([]string{"one"}); !roslices.Equal(singleton.RO, want) {
+		t.Errorf("Reverse(singleton) = %v, want %v", singleton, want)
+	}
+
+	Reverse(&Slice[string]{})
+}
+
+// naiveReplace is a baseline implementation to the Replace function.
+func naiveReplace[E any](s Slice[E], i, j int, v ...E) Slice[E] {
+	s = Delete(s, i, j)
+	s = Insert(s, i, v...)
+	return s
+}
+
+func TestReplace(t *testing.T) {
+	for _, test := range []struct {
+		s, v []int
+		i, j int
+	}{
+		{}, // all zero value
+		{
+			s: []int{1, 2, 3, 4},
+			v: []int{5},
+			i: 1,
+			j: 2,
+		},
+		{
+			s: []int{1, 2, 3, 4},
+			v: []int{5, 6, 7, 8},
+			i: 1,
+			j: 2,
+		},
+		{
+			s: func() []int {
+				s := make([]int, 3, 20)
+				s[0] = 0
+				s[1] = 1
+				s[2] = 2
+				return s
+			}(),
+			v: []int{3, 4, 5, 6, 7},
+			i: 0,
+			j: 1,
+		},
+	} {
+		want := naiveReplace(CopyOnWrite(test.s), test.i, test.j, test.v...)
+		got := Replace(CopyOnWrite(test.s), test.i, test.j, test.v...)
+		if !roslices.Equal(got.RO, want.RO) {
+			t.Errorf("Replace(%v, %v, %v, %v) = %v, want %v", test.s, test.i, test.j, test.v, got, want)
+		}
+	}
+}
+
+func TestReplacePanics(t *testing.T) {
+	s := []int{0, 1, 2, 3, 4}
+	s = s[0:2]
+	_ = s[0:4] // this is a valid slice of s
+
+	for _, test := range []struct {
+		name string
+		s, v []int
+		i, j int
+	}{
+		{"indexes out of order", []int{1, 2}, []int{3}, 2, 1},
+		{"large index", []int{1, 2}, []int{3}, 1, 10},
+		{"negative index", []int{1, 2}, []int{3}, -1, 2},
+		{"s[i:j] is valid and j > len(s)", s, nil, 0, 4},
+	} {
+		ss := CopyOnWrite(test.s)
+		if !panics(func() { _ = Replace(ss, test.i, test.j, test.v...) }) {
+			t.Errorf("Replace %s: should have panicked", test.name)
+		}
+	}
+}
+
+func TestConcat(t *testing.T) {
+	cases := []struct {
+		s    []Slice[int]
+		want []int
+	}{
+		{
+			s:    []Slice[int]{CopyOnWrite[int](nil)},
+			want: nil,
+		},
+		{
+			s:    []Slice[int]{CopyOnWrite([]int{1})},
+			want: []int{1},
+		},
+		{
+			s:    []Slice[int]{CopyOnWrite([]int{1}), CopyOnWrite([]int{2})},
+			want: []int{1, 2},
+		},
+		{
+			s:    []Slice[int]{CopyOnWrite([]int{1}), CopyOnWrite[int](nil), CopyOnWrite([]int{2})},
+			want: []int{1, 2},
+		},
+	}
+	for _, tc := range cases {
+		got := Concat(tc.s...)
+		if !roslices.Equal(roslices.Freeze(tc.want), got.RO) {
+			t.Errorf("Concat(%v) = %v, want %v", tc.s, got, tc.want)
+		}
+		var sink Slice[int]
+		allocs := testing.AllocsPerRun(5, func() {
+			sink = Concat(tc.s...)
+		})
+		_ = sink
+		if allocs > 1 {
+			t.Errorf("Concat(%v) allocated %v times; want 1", tc.s, allocs)
+		}
+	}
+}
+
+func TestRepeat(t *testing.T) {
+	// normal cases
+	for _, tc := range []struct {
+		x     []int
+		count int
+		want  []int
+	}{
+		{x: []int(nil), count: 0, want: []int{}},
+		{x: []int(nil), count: 1, want: []int{}},
+		{x: []int(nil), count: math.MaxInt, want: []int{}},
+		{x: []int{}, count: 0, want: []int{}},
+		{x: []int{}, count: 1, want: []int{}},
+		{x: []int{}, count: math.MaxInt, want: []int{}},
+		{x: []int{0}, count: 0, want: []int{}},
+		{x: []int{0}, count: 1, want: []int{0}},
+		{x: []int{0}, count: 2, want: []int{0, 0}},
+		{x: []int{0}, count: 3, want: []int{0, 0, 0}},
+		{x: []int{0}, count: 4, want: []int{0, 0, 0, 0}},
+		{x: []int{0, 1}, count: 0, want: []int{}},
+		{x: []int{0, 1}, count: 1, want: []int{0, 1}},
+		{x: []int{0, 1}, count: 2, want: []int{0, 1, 0, 1}},
+		{x: []int{0, 1}, count: 3, want: []int{0, 1, 0, 1, 0, 1}},
+		{x: []int{0, 1}, count: 4, want: []int{0, 1, 0, 1, 0, 1, 0, 1}},
+		{x: []int{0, 1, 2}, count: 0, want: []int{}},
+		{x: []int{0, 1, 2}, count: 1, want: []int{0, 1, 2}},
+		{x: []int{0, 1, 2}, count: 2, want: []int{0, 1, 2, 0, 1, 2}},
+		{x: []int{0, 1, 2}, count: 3, want: []int{0, 1, 2, 0, 1, 2, 0, 1, 2}},
+		{x: []int{0, 1, 2}, count: 4, want: []int{0, 1, 2, 0, 1, 2, 0, 1, 2, 0, 1, 2}},
+	} {
+		if got := Repeat(CopyOnWrite(tc.x), tc.count); !roslices.Equal(got.RO, roslices.Freeze(tc.want)) {
+			t.Errorf("Repeat(%v, %v): got: %v, want: %v", tc.x, tc.count, got, tc.want)
+		}
+	}
+}
+
+func TestRepeatPanics(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		x     []struct{}
+		count int
+	}{
+		{name: "cannot be negative", x: make([]struct{}, 0), count: -1},
+		{name: "the result of (len(x) * count) overflows, hi > 0", x: make([]struct{}, 3), count: math.MaxInt},
+		{name: "the result of (len(x) * count) overflows, lo > maxInt", x: make([]struct{}, 2), count: 1 + math.MaxInt/2},
+	} {
+		if !panics(func() { _ = Repeat(CopyOnWrite(test.x), test.count) }) {
+			t.Errorf("Repeat %s: got no panic, want panic", test.name)
+		}
+	}
+}
+
 // These benchmarks compare sorting a large slice of int with sort.Ints vs.
 // Sort
 func makeRandomInts(n int) []int {
@@ -487,7 +731,7 @@ func TestStructSorts(t *testing.T) {
 	ss2 := CopyOnWrite(ss2orig)
 
 	sort.Sort(ss)
-	SortFunc(&ss2, func(a, b *myStruct) bool { return a.n < b.n })
+	SortFunc(&ss2, func(a, b *myStruct) int { return a.n - b.n })
 
 	for i := range ss {
 		if *ss[i] != *ss2.RO.Index(i) {
@@ -506,11 +750,11 @@ func BenchmarkSortStructs(b *testing.B) {
 }
 
 func BenchmarkSortFuncStructs(b *testing.B) {
-	lessFunc := func(a, b *myStruct) bool { return a.n < b.n }
+	cmpFunc := func(a, b *myStruct) int { return a.n - b.n }
 	for i := 0; i < b.N; i++ {
 		b.StopTimer()
 		ss := CopyOnWrite(makeRandomStructs(N))
 		b.StartTimer()
-		SortFunc(&ss, lessFunc)
+		SortFunc(&ss, cmpFunc)
 	}
 }
@@ -5,10 +5,14 @@
 package cowslices_test
 
 import (
+	"expvar"
 	"fmt"
 	"github.com/phelmkamp/immut/cowslices"
 	"github.com/phelmkamp/immut/roslices"
+	"golang.org/x/exp/slices"
+	"math/rand"
 	"reflect"
+	"runtime"
 	"testing"
 	"time"
 )
@@ -136,3 +140,222 @@ func TestSlice_SetIndex(t *testing.T) {
 		t.Errorf("s after SetIndex() = %v, want %v", s, want)
 	}
 }
+
+func TestSlice_copy(t *testing.T) {
+	s := cowslices.CopyOnWrite([]int{1, 2, 3})
+	s.SetIndex(0, 10)
+	c := s
+	ro := s.RO
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			if got, want := c.String(), "[10 2 3]"; got != want {
+				t.Errorf("c.String() = %v, want %v", got, want)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		s.SetIndex(1, i)
+	}
+	<-done
+	if want := roslices.Freeze([]int{10, 2, 3}); !roslices.Equal(ro, want) || !roslices.Equal(c.RO, want) {
+		t.Errorf("RO after SetIndex() = %v, want %v", ro, want)
+	}
+	if want := roslices.Freeze([]int{10, 99, 3}); !roslices.Equal(s.RO, want) {
+		t.Errorf("s after SetIndex() = %v, want %v", s.RO, want)
+	}
+}
+
+func TestAppend(t *testing.T) {
+	orig := []int{1, 2}
+	s := cowslices.CopyOnWrite(orig[:1])
+	got := cowslices.Append(s, 3)
+	if want := roslices.Freeze([]int{1, 3}); !roslices.Equal(got.RO, want) {
+		t.Errorf("Append() = %v, want %v", got, want)
+	}
+	if want := []int{1, 2}; !reflect.DeepEqual(orig, want) {
+		t.Errorf("orig after Append() = %v, want %v", orig, want)
+	}
+	if got := cowslices.Append(s); !reflect.DeepEqual(got, s) {
+		t.Errorf("Append() = %v, want %v", got, s)
+	}
+}
+
+func TestFill(t *testing.T) {
+	orig := []int{1, 2, 3}
+	s := cowslices.CopyOnWrite(orig)
+	cowslices.Fill(&s, 0)
+	if want := roslices.Freeze([]int{0, 0, 0}); !roslices.Equal(s.RO, want) {
+		t.Errorf("s after Fill() = %v, want %v", s, want)
+	}
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(orig, want) {
+		t.Errorf("orig after Fill() = %v, want %v", orig, want)
+	}
+}
+
+func TestSetRange(t *testing.T) {
+	orig := []int{1, 2, 3}
+	s := cowslices.CopyOnWrite(orig)
+	cowslices.SetRange(&s, 1, 4, 5)
+	if want := roslices.Freeze([]int{1, 4, 5}); !roslices.Equal(s.RO, want) {
+		t.Errorf("s after SetRange() = %v, want %v", s, want)
+	}
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(orig, want) {
+		t.Errorf("orig after SetRange() = %v, want %v", orig, want)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("SetRange() did not panic")
+		}
+	}()
+	cowslices.SetRange(&s, 2, 6, 7)
+}
+
+func TestSwap(t *testing.T) {
+	orig := []int{1, 2, 3}
+	s := cowslices.CopyOnWrite(orig)
+	cowslices.Swap(&s, 0, 2)
+	if want := roslices.Freeze([]int{3, 2, 1}); !roslices.Equal(s.RO, want) {
+		t.Errorf("s after Swap() = %v, want %v", s, want)
+	}
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(orig, want) {
+		t.Errorf("orig after Swap() = %v, want %v", orig, want)
+	}
+}
+
+func TestTransient(t *testing.T) {
+	orig := []int{1, 2, 3}
+	ts := cowslices.Transient(cowslices.CopyOnWrite(orig))
+	for i := 4; i <= 6; i++ {
+		ts.Append(i)
+	}
+	ts.SetIndex(0, 0)
+	ts.Delete(1, 2)
+	ts.Insert(1, 7)
+	ts.Do(cowslices.DoReverse[int]())
+	if got := ts.Index(0); got != 6 {
+		t.Errorf("Index() = %v, want %v", got, 6)
+	}
+	s := ts.Persistent()
+	if want := roslices.Freeze([]int{6, 5, 4, 3, 7, 0}); !roslices.Equal(s.RO, want) {
+		t.Errorf("Persistent() = %v, want %v", s, want)
+	}
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(orig, want) {
+		t.Errorf("orig after Persistent() = %v, want %v", orig, want)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Append() after Persistent() did not panic")
+		}
+	}()
+	ts.Append(8)
+}
+
+func TestSort_parallel(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	defer cowslices.SetParallelSortThreshold(cowslices.SetParallelSortThreshold(64))
+
+	type pair struct{ k, v int }
+	r := rand.New(rand.NewSource(1))
+	ints := make([]int, 10_000)
+	pairs := make([]pair, len(ints))
+	for i := range ints {
+		ints[i] = r.Intn(100)
+		pairs[i] = pair{ints[i], i}
+	}
+	cmp := func(a, b pair) int { return a.k - b.k }
+
+	s := cowslices.CopyOnWrite(ints)
+	cowslices.Sort(&s)
+	want := slices.Clone(ints)
+	slices.Sort(want)
+	if !roslices.Equal(s.RO, roslices.Freeze(want)) {
+		t.Errorf("Sort() = %v, want %v", s, want)
+	}
+
+	s = cowslices.CopyOnWrite(ints)
+	cowslices.SortFunc(&s, func(a, b int) int { return b - a })
+	slices.SortFunc(want, func(a, b int) bool { return b < a })
+	if !roslices.Equal(s.RO, roslices.Freeze(want)) {
+		t.Errorf("SortFunc() = %v, want %v", s, want)
+	}
+
+	wantPairs := slices.Clone(pairs)
+	slices.SortStableFunc(wantPairs, func(a, b pair) bool { return a.k < b.k })
+	ps := cowslices.CopyOnWrite(pairs)
+	cowslices.SortStableFunc(&ps, cmp)
+	if !roslices.Equal(ps.RO, roslices.Freeze(wantPairs)) {
+		t.Errorf("SortStableFunc() = %v, want %v", ps, wantPairs)
+	}
+	ps = cowslices.DoAll(cowslices.CopyOnWrite(pairs), 0, cowslices.DoSortStableFunc(cmp))
+	if !roslices.Equal(ps.RO, roslices.Freeze(wantPairs)) {
+		t.Errorf("DoSortStableFunc() = %v, want %v", ps, wantPairs)
+	}
+}
+
+func TestStats(t *testing.T) {
+	cowslices.EnableStats(true)
+	defer cowslices.EnableStats(false)
+	cowslices.ResetStats()
+	defer cowslices.ResetStats()
+
+	s := cowslices.CopyOnWrite([]int{2, 1, 3})
+	cowslices.Sort(&s)
+	cowslices.Sort(&s)
+	s = cowslices.Append(s)
+	got := cowslices.Stats()
+	want := map[string]cowslices.OpStats{
+		"Sort":   {Clones: 1, Copied: 3, FastPaths: 1},
+		"Append": {FastPaths: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Stats() = %v, want %v", got, want)
+	}
+	if v := expvar.Get("cowslices"); v == nil || v.String() == "" {
+		t.Errorf("expvar.Get() = %v, want non-empty", v)
+	}
+
+	cowslices.EnableStats(false)
+	cowslices.Reverse(&s)
+	if got := cowslices.Stats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Stats() after EnableStats(false) = %v, want %v", got, want)
+	}
+}
+
+func TestCopyOnWriteDeep(t *testing.T) {
+	orig := [][]int{{1, 2}, {3}}
+	s := cowslices.CopyOnWriteDeep(orig, slices.Clone[[]int])
+	s2 := s
+	s2.Update(0, func(v []int) []int {
+		v[0] = 0
+		return v
+	})
+	if want := [][]int{{1, 2}, {3}}; !reflect.DeepEqual(orig, want) {
+		t.Errorf("orig after Update() = %v, want %v", orig, want)
+	}
+	if got, want := cowslices.IndexSlice(s2, 0), roslices.Freeze([]int{0, 2}); !roslices.Equal(got, want) {
+		t.Errorf("IndexSlice() = %v, want %v", got, want)
+	}
+	if got, want := s.Clone(), [][]int{{1, 2}, {3}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Clone() = %v, want %v", got, want)
+	}
+
+	type point struct{ x, y int }
+	ps := cowslices.CopyOnWriteDeep([]*point{{1, 2}}, func(p *point) *point {
+		p2 := *p
+		return &p2
+	})
+	ps2 := ps
+	ps2.Update(0, func(p *point) *point {
+		p.x = 0
+		return p
+	})
+	if got := cowslices.IndexPointer(ps, 0).Clone(); *got != (point{1, 2}) {
+		t.Errorf("IndexPointer() = %v, want %v", *got, point{1, 2})
+	}
+	if got := ps2.IndexClone(0); *got != (point{0, 2}) {
+		t.Errorf("IndexClone() = %v, want %v", *got, point{0, 2})
+	}
+}
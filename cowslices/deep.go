@@ -0,0 +1,81 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package cowslices
+
+import (
+	"fmt"
+
+	"github.com/phelmkamp/immut/corptrs"
+	"github.com/phelmkamp/immut/romaps"
+	"github.com/phelmkamp/immut/roslices"
+)
+
+// DeepSlice wraps a copy-on-write slice of mutable elements, such as pointers, slices or maps.
+// Elements are shared between versions, so they are only accessible read-only.
+// Writing to an element through Update clones just that element.
+type DeepSlice[E any] struct {
+	s     Slice[E]
+	clone func(E) E
+}
+
+// Clone returns a copy of the slice in which every element is cloned.
+func (s DeepSlice[E]) Clone() []E {
+	ro := s.s.RO
+	s2 := make([]E, ro.Len())
+	for i := range s2 {
+		s2[i] = s.clone(ro.Index(i))
+	}
+	return s2
+}
+
+// IndexClone returns a clone of the i'th element.
+func (s DeepSlice[E]) IndexClone(i int) E {
+	return s.clone(s.s.RO.Index(i))
+}
+
+// Len returns the number of elements in the slice.
+func (s DeepSlice[E]) Len() int {
+	return s.s.RO.Len()
+}
+
+// SetIndex sets the element at i to v.
+// v must not be modified afterwards.
+// Note: The underlying slice is cloned before the write-operation is performed.
+func (s *DeepSlice[E]) SetIndex(i int, v E) {
+	s.s.SetIndex(i, v)
+}
+
+// String returns the underlying slice formatted as a string.
+func (s DeepSlice[E]) String() string {
+	return fmt.Sprint(s.s.RO)
+}
+
+// Update sets the element at i to the result of calling f with a clone of it.
+// f may modify its argument without affecting other versions of the slice.
+// Note: The underlying slice is cloned before the write-operation is performed.
+func (s *DeepSlice[E]) Update(i int, f func(E) E) {
+	s.s.SetIndex(i, f(s.IndexClone(i)))
+}
+
+// CopyOnWriteDeep returns a copy-on-write wrapper for the given slice
+// that uses clone to copy an element before it is modified.
+func CopyOnWriteDeep[E any](s []E, clone func(E) E) DeepSlice[E] {
+	return DeepSlice[E]{s: CopyOnWrite(s), clone: clone}
+}
+
+// IndexMap returns the i'th element of s as a read-only map.
+func IndexMap[K comparable, V any](s DeepSlice[map[K]V], i int) romaps.Map[K, V] {
+	return romaps.Freeze(s.s.RO.Index(i))
+}
+
+// IndexPointer returns the i'th element of s as a read-only pointer.
+func IndexPointer[T any](s DeepSlice[*T], i int) corptrs.Pointer[T] {
+	return corptrs.Freeze(s.s.RO.Index(i))
+}
+
+// IndexSlice returns the i'th element of s as a read-only slice.
+func IndexSlice[E any](s DeepSlice[[]E], i int) roslices.Slice[E] {
+	return roslices.Freeze(s.s.RO.Index(i))
+}
@@ -0,0 +1,176 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package cowslices
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/phelmkamp/immut/roslices"
+	"golang.org/x/exp/slices"
+)
+
+// Edit is an operation that replaces the elements s[I:I+len(Del)] with Ins.
+// I is relative to the slice as modified by the preceding edits.
+type Edit[E any] struct {
+	I   int
+	Del []E
+	Ins []E
+}
+
+// String returns the edit formatted as "@I -Del +Ins".
+func (e Edit[E]) String() string {
+	return fmt.Sprintf("@%d -%v +%v", e.I, e.Del, e.Ins)
+}
+
+func (e Edit[E]) do(s []E) []E {
+	checkSlice(e.I, e.I+len(e.Del), len(s))
+	return slices.Insert(slices.Delete(s, e.I, e.I+len(e.Del)), e.I, e.Ins...)
+}
+
+// Diff returns a minimal edit script that transforms old into new.
+// Each operation is an Edit; doing them in order with DoAll on old produces new.
+func Diff[E comparable](old, new roslices.Slice[E]) []Doer[E] {
+	return DiffFunc(old, new, func(a, b E) bool { return a == b })
+}
+
+// DiffFunc is like Diff but uses a comparison function on each pair of elements.
+func DiffFunc[E any](old, new roslices.Slice[E], eq func(E, E) bool) []Doer[E] {
+	// trim common prefix and suffix
+	n, m := old.Len(), new.Len()
+	pre := 0
+	for pre < n && pre < m && eq(old.Index(pre), new.Index(pre)) {
+		pre++
+	}
+	suf := 0
+	for suf < n-pre && suf < m-pre && eq(old.Index(n-1-suf), new.Index(m-1-suf)) {
+		suf++
+	}
+	old, new = old.Slice(pre, n-suf), new.Slice(pre, m-suf)
+
+	ops := myers(old.Len(), new.Len(), func(x, y int) bool {
+		return eq(old.Index(x), new.Index(y))
+	})
+
+	var edits []Doer[E]
+	x, y, i := 0, 0, pre
+	for k := 0; k < len(ops); {
+		if ops[k] == opKeep {
+			x, y, i, k = x+1, y+1, i+1, k+1
+			continue
+		}
+		x0, y0 := x, y
+		for ; k < len(ops) && ops[k] != opKeep; k++ {
+			if ops[k] == opDel {
+				x++
+			} else {
+				y++
+			}
+		}
+		e := Edit[E]{
+			I:   i,
+			Del: roslices.Clone(old.Slice(x0, x)),
+			Ins: roslices.Clone(new.Slice(y0, y)),
+		}
+		edits = append(edits, e)
+		i += len(e.Ins)
+	}
+	return edits
+}
+
+// FormatDiff returns a line-by-line representation of edits applied to old.
+// Each line is the element prefixed by "  " if it is kept, "- " if it is deleted, or "+ " if it is inserted.
+// edits must be the result of Diff or DiffFunc for old.
+func FormatDiff[E any](old roslices.Slice[E], edits []Doer[E]) string {
+	var sb strings.Builder
+	x, off := 0, 0 // off is the difference between positions in old and the modified slice
+	for _, op := range edits {
+		e := op.(Edit[E])
+		for ; x < e.I-off; x++ {
+			fmt.Fprintf(&sb, "  %v\n", old.Index(x))
+		}
+		for _, v := range e.Del {
+			fmt.Fprintf(&sb, "- %v\n", v)
+		}
+		for _, v := range e.Ins {
+			fmt.Fprintf(&sb, "+ %v\n", v)
+		}
+		x += len(e.Del)
+		off += len(e.Ins) - len(e.Del)
+	}
+	for ; x < old.Len(); x++ {
+		fmt.Fprintf(&sb, "  %v\n", old.Index(x))
+	}
+	return sb.String()
+}
+
+const (
+	opKeep byte = iota
+	opDel
+	opIns
+)
+
+// myers returns the shortest edit script between sequences of length n and m
+// using the algorithm described in "An O(ND) Difference Algorithm and Its Variations" (Myers, 1986).
+func myers(n, m int, eq func(x, y int) bool) []byte {
+	max := n + m
+	off := max + 1
+	v := make([]int, 2*max+3)
+	// trace[d] holds v[-d-1:d+2] as it was before step d
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		trace = append(trace, slices.Clone(v[off-d-1:off+d+2]))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1] // down: insert
+			} else {
+				x = v[off+k-1] + 1 // right: delete
+			}
+			y := x - k
+			for x < n && y < m && eq(x, y) {
+				x, y = x+1, y+1
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, n, m)
+			}
+		}
+	}
+	panic("unreachable")
+}
+
+// backtrack walks trace from (n, m) back to (0, 0) and returns the edit script.
+func backtrack(trace [][]int, n, m int) []byte {
+	var ops []byte
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d+1] }
+		k := x - y
+		var pk int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			pk = k + 1
+		} else {
+			pk = k - 1
+		}
+		px := at(pk)
+		py := px - pk
+		for x > px && y > py {
+			ops = append(ops, opKeep)
+			x, y = x-1, y-1
+		}
+		if d > 0 {
+			if x == px {
+				ops = append(ops, opIns)
+			} else {
+				ops = append(ops, opDel)
+			}
+		}
+		x, y = px, py
+	}
+	reverse(ops)
+	return ops
+}
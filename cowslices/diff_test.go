@@ -0,0 +1,98 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package cowslices_test
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/phelmkamp/immut/cowslices"
+	"github.com/phelmkamp/immut/roslices"
+)
+
+func ExampleDiff() {
+	old := cowslices.CopyOnWrite(strings.Split("ABCABBA", ""))
+	new := roslices.Freeze(strings.Split("CBABAC", ""))
+	edits := cowslices.Diff(old.RO, new)
+	fmt.Println(edits)
+	fmt.Println(cowslices.DoAll(old, new.Len(), edits...))
+	fmt.Print(cowslices.FormatDiff(old.RO, edits))
+	// Output: [@0 -[A B] +[] @1 -[] +[B] @4 -[B] +[] @5 -[] +[C]]
+	// [C B A B A C]
+	// - A
+	// - B
+	//   C
+	// + B
+	//   A
+	//   B
+	// - B
+	//   A
+	// + C
+}
+
+// lcs returns the length of the longest common subsequence of a and b.
+func lcs(a, b []int) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			switch {
+			case a[i-1] == b[j-1]:
+				dp[i][j] = dp[i-1][j-1] + 1
+			case dp[i-1][j] > dp[i][j-1]:
+				dp[i][j] = dp[i-1][j]
+			default:
+				dp[i][j] = dp[i][j-1]
+			}
+		}
+	}
+	return dp[len(a)][len(b)]
+}
+
+func randInts(r *rand.Rand, n int) []int {
+	s := make([]int, r.Intn(n))
+	for i := range s {
+		s[i] = r.Intn(4)
+	}
+	return s
+}
+
+func TestDiff(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		a, b := randInts(r, 20), randInts(r, 20)
+		edits := cowslices.Diff(roslices.Freeze(a), roslices.Freeze(b))
+
+		got := cowslices.DoAll(cowslices.CopyOnWrite(a), 0, edits...)
+		if !roslices.Equal(got.RO, roslices.Freeze(b)) {
+			t.Fatalf("DoAll(%v, Diff()) = %v, want %v", a, got, b)
+		}
+
+		n := 0
+		for _, op := range edits {
+			e := op.(cowslices.Edit[int])
+			n += len(e.Del) + len(e.Ins)
+		}
+		if want := len(a) + len(b) - 2*lcs(a, b); n != want {
+			t.Fatalf("Diff(%v, %v) has %v changes, want %v", a, b, n, want)
+		}
+	}
+}
+
+func TestDiffFunc(t *testing.T) {
+	old := roslices.Freeze([]string{"a", "B", "c"})
+	new := roslices.Freeze([]string{"A", "b", "d"})
+	got := cowslices.DiffFunc(old, new, strings.EqualFold)
+	if want := "[@2 -[c] +[d]]"; fmt.Sprint(got) != want {
+		t.Errorf("DiffFunc() = %v, want %v", got, want)
+	}
+	if got := cowslices.Diff(old, old); len(got) != 0 {
+		t.Errorf("Diff() = %v, want %v", got, "[]")
+	}
+}
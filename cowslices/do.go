@@ -11,6 +11,7 @@ import (
 )
 
 // Doer defines a method for doing an operation on a slice.
+// Custom operations may be defined with DoFunc.
 type Doer[E any] interface {
 	do([]E) []E
 }
@@ -31,13 +32,21 @@ func DoAll[E any](s Slice[E], cap int, ops ...Doer[E]) Slice[E] {
 		cap = ro.Len()
 	}
 	s2 := clone(ro, cap)
+	stats.Clone(opDoAll, ro.Len())
 	for _, op := range ops {
 		s2 = op.do(s2)
 	}
-	s.RO = roslices.Freeze(s2)
+	s.set(s2)
 	return s
 }
 
+// DoAppend returns the append operation.
+func DoAppend[E any](v ...E) Doer[E] {
+	return doerFunc[E](func(s []E) []E {
+		return append(s, v...)
+	})
+}
+
 // DoClip returns the slices.Clip operation.
 func DoClip[E any]() Doer[E] {
 	return doerFunc[E](func(s []E) []E {
@@ -59,6 +68,16 @@ func DoCompactFunc[E any](eq func(E, E) bool) Doer[E] {
 	})
 }
 
+// DoConcat returns the operation that appends the elements of each of ss.
+func DoConcat[E any](ss ...roslices.Slice[E]) Doer[E] {
+	return doerFunc[E](func(s []E) []E {
+		for _, s2 := range ss {
+			s = appendRO(slices.Grow(s, s2.Len()), s2)
+		}
+		return s
+	})
+}
+
 // DoDelete returns the slices.Delete operation.
 func DoDelete[E any](i, j int) Doer[E] {
 	return doerFunc[E](func(s []E) []E {
@@ -66,6 +85,38 @@ func DoDelete[E any](i, j int) Doer[E] {
 	})
 }
 
+// DoDeleteFunc returns the slices.DeleteFunc operation.
+func DoDeleteFunc[E any](del func(E) bool) Doer[E] {
+	return doerFunc[E](func(s []E) []E {
+		return deleteFunc(s, del)
+	})
+}
+
+// DoFill returns the operation that sets every element to v.
+func DoFill[E any](v E) Doer[E] {
+	return doerFunc[E](func(s []E) []E {
+		setAll(s, v)
+		return s
+	})
+}
+
+// DoFunc returns the operation defined by f.
+// f is called with the slice being modified by DoAll and must return the modified slice.
+// f must not retain its argument, because later operations modify it in place.
+func DoFunc[E any](f func([]E) []E) Doer[E] {
+	return doerFunc[E](f)
+}
+
+// DoIf returns op if cond is true, otherwise it returns an operation that does nothing.
+func DoIf[E any](cond bool, op Doer[E]) Doer[E] {
+	if !cond {
+		return doerFunc[E](func(s []E) []E {
+			return s
+		})
+	}
+	return op
+}
+
 // DoInsert returns the slices.Insert operation.
 func DoInsert[E any](i int, v ...E) Doer[E] {
 	return doerFunc[E](func(s []E) []E {
@@ -73,26 +124,90 @@ func DoInsert[E any](i int, v ...E) Doer[E] {
 	})
 }
 
+// DoRepeat returns the slices.Repeat operation.
+func DoRepeat[E any](count int) Doer[E] {
+	return doerFunc[E](func(s []E) []E {
+		checkRepeat(len(s), count)
+		return repeat(roslices.Freeze(s), count)
+	})
+}
+
+// DoReplace returns the slices.Replace operation.
+func DoReplace[E any](i, j int, v ...E) Doer[E] {
+	return doerFunc[E](func(s []E) []E {
+		checkSlice(i, j, len(s))
+		return slices.Insert(slices.Delete(s, i, j), i, v...)
+	})
+}
+
+// DoReverse returns the slices.Reverse operation.
+func DoReverse[E any]() Doer[E] {
+	return doerFunc[E](func(s []E) []E {
+		reverse(s)
+		return s
+	})
+}
+
+// DoSeq returns the operation that does each of ops in order.
+func DoSeq[E any](ops ...Doer[E]) Doer[E] {
+	return doerFunc[E](func(s []E) []E {
+		for _, op := range ops {
+			s = op.do(s)
+		}
+		return s
+	})
+}
+
+// DoSetRange returns the operation that sets the elements s[i:i+len(v)] to v.
+func DoSetRange[E any](i int, v ...E) Doer[E] {
+	return doerFunc[E](func(s []E) []E {
+		checkSlice(i, i+len(v), len(s))
+		copy(s[i:], v)
+		return s
+	})
+}
+
 // DoSort returns the slices.Sort operation.
 func DoSort[E constraints.Ordered]() Doer[E] {
 	return doerFunc[E](func(x []E) []E {
-		slices.Sort(x)
+		sortOrdered(x)
 		return x
 	})
 }
 
 // DoSortFunc returns the slices.SortFunc operation.
-func DoSortFunc[E any](less func(a, b E) bool) Doer[E] {
+func DoSortFunc[E any](cmp func(a, b E) int) Doer[E] {
 	return doerFunc[E](func(x []E) []E {
-		slices.SortFunc(x, less)
+		sortLessFunc(x, lessFunc(cmp), false)
 		return x
 	})
 }
 
 // DoSortStableFunc returns the slices.SortStableFunc operation.
-func DoSortStableFunc[E any](less func(a, b E) bool) Doer[E] {
+func DoSortStableFunc[E any](cmp func(a, b E) int) Doer[E] {
 	return doerFunc[E](func(x []E) []E {
-		slices.SortStableFunc(x, less)
+		sortLessFunc(x, lessFunc(cmp), true)
 		return x
 	})
 }
+
+// DoSwap returns the operation that swaps the elements at i and j.
+func DoSwap[E any](i, j int) Doer[E] {
+	return doerFunc[E](func(s []E) []E {
+		s[i], s[j] = s[j], s[i]
+		return s
+	})
+}
+
+// DoWhen returns the operation that does op only if pred reports true
+// for the slice as modified by the preceding operations.
+// The slice passed to pred is only valid for the duration of the call;
+// pred must not retain it, because later operations modify it in place.
+func DoWhen[E any](pred func(roslices.Slice[E]) bool, op Doer[E]) Doer[E] {
+	return doerFunc[E](func(s []E) []E {
+		if !pred(roslices.Freeze(s)) {
+			return s
+		}
+		return op.do(s)
+	})
+}
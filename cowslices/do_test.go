@@ -7,6 +7,7 @@ package cowslices_test
 import (
 	"fmt"
 	"github.com/phelmkamp/immut/cowslices"
+	"github.com/phelmkamp/immut/roslices"
 	"reflect"
 	"testing"
 )
@@ -44,10 +45,66 @@ func TestDoAll(t *testing.T) {
 func TestDoAll_func(t *testing.T) {
 	s1 := cowslices.CopyOnWrite([][]int{{1}, {1, 2}, {1}})
 	got := cowslices.DoAll(s1, s1.RO.Len(),
-		cowslices.DoSortFunc[[]int](func(a, b []int) bool { return len(a) < len(b) }),            // [[1] [1] [1 2]]
+		cowslices.DoSortFunc[[]int](func(a, b []int) int { return len(a) - len(b) }),             // [[1] [1] [1 2]]
 		cowslices.DoCompactFunc[[]int](func(a, b []int) bool { return reflect.DeepEqual(a, b) }), // [[1] [1 2]]
 	)
 	if want := cowslices.CopyOnWrite([][]int{{1}, {1, 2}}); !reflect.DeepEqual(got, want) {
 		t.Errorf("All() = %v, want %v", got, want)
 	}
 }
+
+func TestDoAll_more(t *testing.T) {
+	s1 := cowslices.CopyOnWrite([]int{1, 2, 3})
+	got := cowslices.DoAll(s1, 0,
+		cowslices.DoAppend(4, 5),                                        // [1 2 3 4 5]
+		cowslices.DoDeleteFunc(func(v int) bool { return v == 2 }),      // [1 3 4 5]
+		cowslices.DoReplace(1, 3, 6),                                    // [1 6 5]
+		cowslices.DoReverse[int](),                                      // [5 6 1]
+		cowslices.DoSwap[int](0, 2),                                     // [1 6 5]
+		cowslices.DoConcat(roslices.Freeze([]int{7})),                   // [1 6 5 7]
+		cowslices.DoRepeat[int](2),                                      // [1 6 5 7 1 6 5 7]
+		cowslices.DoSetRange(6, 8, 9),                                   // [1 6 5 7 1 6 8 9]
+		cowslices.DoSortStableFunc(func(a, b int) int { return a - b }), // [1 1 5 6 6 7 8 9]
+	)
+	if want := roslices.Freeze([]int{1, 1, 5, 6, 6, 7, 8, 9}); !roslices.Equal(got.RO, want) {
+		t.Errorf("DoAll() = %v, want %v", got, want)
+	}
+	got = cowslices.DoAll(got, 0, cowslices.DoFill(0))
+	if want := roslices.Freeze(make([]int, 8)); !roslices.Equal(got.RO, want) {
+		t.Errorf("DoAll() = %v, want %v", got, want)
+	}
+	if want := roslices.Freeze([]int{1, 2, 3}); !roslices.Equal(s1.RO, want) {
+		t.Errorf("s1 after DoAll() = %v, want %v", s1, want)
+	}
+}
+
+func ExampleDoFunc() {
+	double := cowslices.DoFunc(func(s []int) []int {
+		for i := range s {
+			s[i] *= 2
+		}
+		return s
+	})
+	s := cowslices.CopyOnWrite([]int{3, 1, 2})
+	s = cowslices.DoAll(s, 0,
+		cowslices.DoSort[int](),
+		cowslices.DoWhen(func(s roslices.Slice[int]) bool { return s.Index(0) == 1 }, double),
+	)
+	fmt.Println(s)
+	// Output: [2 4 6]
+}
+
+func TestDoAll_combinators(t *testing.T) {
+	s1 := cowslices.CopyOnWrite([]int{1, 2})
+	long := func(s roslices.Slice[int]) bool { return s.Len() > 3 }
+	got := cowslices.DoAll(s1, 0,
+		cowslices.DoIf(false, cowslices.DoAppend(0)),                           // [1 2]
+		cowslices.DoIf(true, cowslices.DoAppend(3)),                            // [1 2 3]
+		cowslices.DoWhen(long, cowslices.DoAppend(0)),                          // [1 2 3]
+		cowslices.DoSeq(cowslices.DoAppend(4), cowslices.DoReverse[int]()),     // [4 3 2 1]
+		cowslices.DoWhen(long, cowslices.DoSeq(cowslices.DoDelete[int](0, 1))), // [3 2 1]
+	)
+	if want := roslices.Freeze([]int{3, 2, 1}); !roslices.Equal(got.RO, want) {
+		t.Errorf("DoAll() = %v, want %v", got, want)
+	}
+}
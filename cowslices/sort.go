@@ -0,0 +1,81 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package cowslices
+
+import (
+	"math/bits"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/exp/constraints"
+	"golang.org/x/exp/slices"
+)
+
+// DefaultParallelSortThreshold is the initial threshold for parallel sorting (see SetParallelSortThreshold).
+const DefaultParallelSortThreshold = 1 << 17
+
+var parallelSortThreshold atomic.Int64
+
+func init() {
+	parallelSortThreshold.Store(DefaultParallelSortThreshold)
+}
+
+// SetParallelSortThreshold sets the minimum length of a slice that is sorted on multiple goroutines
+// by Sort, SortFunc, SortStableFunc and their Doers, and returns the previous threshold.
+// Chunks of the cloned slice are sorted concurrently and then merged in place,
+// so no memory is allocated beyond the clone.
+// The result of a stable sort is the same regardless of this setting.
+// A value less than 1 disables parallel sorting.
+// It is safe to call concurrently with sorting.
+func SetParallelSortThreshold(n int) int {
+	return int(parallelSortThreshold.Swap(int64(n)))
+}
+
+// sortOrdered is like slices.Sort but sorts large slices in parallel.
+func sortOrdered[E constraints.Ordered](s []E) {
+	sortParallel(s, slices.Sort[E], func(a, b E) bool { return a < b })
+}
+
+// sortLessFunc is like slices.SortFunc or slices.SortStableFunc but sorts large slices in parallel.
+func sortLessFunc[E any](s []E, less func(a, b E) bool, stable bool) {
+	sortChunk := func(s []E) { slices.SortFunc(s, less) }
+	if stable {
+		sortChunk = func(s []E) { slices.SortStableFunc(s, less) }
+	}
+	sortParallel(s, sortChunk, less)
+}
+
+func sortParallel[E any](s []E, sortChunk func([]E), less func(a, b E) bool) {
+	procs := runtime.GOMAXPROCS(0)
+	threshold := parallelSortThreshold.Load()
+	if threshold < 1 || int64(len(s)) < threshold || procs < 2 {
+		sortChunk(s)
+		return
+	}
+	// split into at least procs chunks
+	mergeSort(s, sortChunk, less, bits.Len(uint(procs-1)))
+}
+
+// mergeSort sorts the halves of s concurrently, recursing depth times, then merges them in place.
+func mergeSort[E any](s []E, sortChunk func([]E), less func(a, b E) bool, depth int) {
+	if depth == 0 || len(s) < 2 {
+		sortChunk(s)
+		return
+	}
+	m := len(s) / 2
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		mergeSort(s[:m], sortChunk, less, depth-1)
+	}()
+	mergeSort(s[m:], sortChunk, less, depth-1)
+	wg.Wait()
+	// Avoid merge if halves are already in order.
+	if less(s[m], s[m-1]) {
+		symMerge(s, 0, m, len(s), less)
+	}
+}
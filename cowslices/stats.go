@@ -0,0 +1,90 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package cowslices
+
+import (
+	"github.com/phelmkamp/immut/internal/cowstats"
+)
+
+const (
+	opAppend = iota
+	opClip
+	opCompact
+	opCompactFunc
+	opConcat
+	opDelete
+	opDeleteFunc
+	opDoAll
+	opFill
+	opGrow
+	opInsert
+	opRepeat
+	opReplace
+	opReverse
+	opSetIndex
+	opSetRange
+	opSort
+	opSortFunc
+	opSortStableFunc
+	opSwap
+	opTransient
+	numOps
+)
+
+var opNames = [numOps]string{
+	opAppend:         "Append",
+	opClip:           "Clip",
+	opCompact:        "Compact",
+	opCompactFunc:    "CompactFunc",
+	opConcat:         "Concat",
+	opDelete:         "Delete",
+	opDeleteFunc:     "DeleteFunc",
+	opDoAll:          "DoAll",
+	opFill:           "Fill",
+	opGrow:           "Grow",
+	opInsert:         "Insert",
+	opRepeat:         "Repeat",
+	opReplace:        "Replace",
+	opReverse:        "Reverse",
+	opSetIndex:       "SetIndex",
+	opSetRange:       "SetRange",
+	opSort:           "Sort",
+	opSortFunc:       "SortFunc",
+	opSortStableFunc: "SortStableFunc",
+	opSwap:           "Swap",
+	opTransient:      "Transient",
+}
+
+var stats = cowstats.New("cowslices", opNames[:]...)
+
+// OpStats holds the clone counters for one operation.
+type OpStats struct {
+	Clones    int64 // number of times the underlying slice was cloned
+	Copied    int64 // number of elements copied into clones
+	FastPaths int64 // number of writes that avoided a clone
+}
+
+// EnableStats enables or disables counting clones.
+// Counting is disabled by default.
+// Once enabled, the counters are also published via expvar as "cowslices".
+func EnableStats(enabled bool) {
+	stats.Enable(enabled)
+}
+
+// ResetStats sets all clone counters to zero.
+func ResetStats() {
+	stats.Reset()
+}
+
+// Stats returns the current clone counters keyed by operation name.
+// Operations that have not been counted are omitted.
+func Stats() map[string]OpStats {
+	snap := stats.Snapshot()
+	m := make(map[string]OpStats, len(snap))
+	for op, st := range snap {
+		m[op] = OpStats(st)
+	}
+	return m
+}
@@ -0,0 +1,85 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package cowslices
+
+import (
+	"golang.org/x/exp/slices"
+)
+
+// TransientSlice is a private, mutable copy of a Slice for bulk mutation.
+// Writes are performed in place until Persistent is called, after which any use panics.
+// A TransientSlice is not safe for concurrent use by multiple goroutines.
+type TransientSlice[E any] struct {
+	s    []E
+	done bool
+}
+
+// Append appends v to the end of the slice.
+func (t *TransientSlice[E]) Append(v ...E) {
+	t.check()
+	t.s = append(t.s, v...)
+}
+
+// Delete removes the elements s[i:j] from the slice.
+func (t *TransientSlice[E]) Delete(i, j int) {
+	t.check()
+	t.s = slices.Delete(t.s, i, j)
+}
+
+// Do does the supplied operations on the slice in place.
+func (t *TransientSlice[E]) Do(ops ...Doer[E]) {
+	t.check()
+	for _, op := range ops {
+		t.s = op.do(t.s)
+	}
+}
+
+// Index returns the i'th element of the slice.
+func (t *TransientSlice[E]) Index(i int) E {
+	t.check()
+	return t.s[i]
+}
+
+// Insert inserts v at index i.
+func (t *TransientSlice[E]) Insert(i int, v ...E) {
+	t.check()
+	t.s = slices.Insert(t.s, i, v...)
+}
+
+// Len returns the number of elements in the slice.
+func (t *TransientSlice[E]) Len() int {
+	t.check()
+	return len(t.s)
+}
+
+// Persistent returns the slice as a Slice without copying it.
+// The TransientSlice must not be used afterwards.
+func (t *TransientSlice[E]) Persistent() Slice[E] {
+	t.check()
+	t.done = true
+	s := CopyOnWrite(t.s)
+	t.s = nil
+	return s
+}
+
+// SetIndex sets the i'th element of the slice to v.
+func (t *TransientSlice[E]) SetIndex(i int, v E) {
+	t.check()
+	t.s[i] = v
+}
+
+func (t *TransientSlice[E]) check() {
+	if t.done {
+		panic("cowslices: use of TransientSlice after Persistent")
+	}
+}
+
+// Transient returns a TransientSlice initialized with a private copy of s.
+// Note: The underlying slice is cloned once, up front.
+func Transient[E any](s Slice[E]) *TransientSlice[E] {
+	ro := s.RO
+	stats.Clone(opTransient, ro.Len())
+	return &TransientSlice[E]{s: clone(ro, ro.Len())}
+}
@@ -0,0 +1,6 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package history defines an undo/redo history useful with copy-on-write values of any type.
+package history
@@ -0,0 +1,92 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package history
+
+// History records committed versions of a value.
+// Recording a copy-on-write value such as a cowslices.Slice or cowmaps.Map is O(1)
+// because old versions are never modified; every write produces a new underlying value instead.
+// A History is not safe for concurrent use by multiple goroutines.
+// The zero value is not usable; create a History with New.
+type History[T any] struct {
+	versions []T // retained versions, oldest first
+	first    int // version number of versions[0]
+	cur      int // index of the current version in versions
+	limit    int
+}
+
+// At returns the value as of the given version.
+// The boolean value ok is false if the version has not been committed or is no longer retained.
+func (h *History[T]) At(version int) (v T, ok bool) {
+	i := version - h.first
+	if i < 0 || i >= len(h.versions) {
+		return v, false
+	}
+	return h.versions[i], true
+}
+
+// Commit records v as a new version and makes it current, returning its version number.
+// Any versions that could be redone are discarded.
+// If the number of retained versions exceeds the limit, the oldest is discarded.
+// Note: v must not be modified after it is committed.
+func (h *History[T]) Commit(v T) int {
+	var zero T
+	for i := h.cur + 1; i < len(h.versions); i++ {
+		h.versions[i] = zero // avoid memory leak
+	}
+	h.versions = append(h.versions[:h.cur+1], v)
+	h.cur++
+	if h.limit > 0 && len(h.versions) > h.limit {
+		n := len(h.versions) - h.limit
+		copy(h.versions, h.versions[n:])
+		for i := h.limit; i < len(h.versions); i++ {
+			h.versions[i] = zero // avoid memory leak
+		}
+		h.versions = h.versions[:h.limit]
+		h.first += n
+		h.cur -= n
+	}
+	return h.Version()
+}
+
+// Current returns the current value.
+func (h *History[T]) Current() T {
+	return h.versions[h.cur]
+}
+
+// Len returns the number of retained versions.
+func (h *History[T]) Len() int {
+	return len(h.versions)
+}
+
+// Redo makes the next version current and returns it.
+// The boolean value ok is false if there is no version to redo.
+func (h *History[T]) Redo() (v T, ok bool) {
+	if h.cur+1 >= len(h.versions) {
+		return v, false
+	}
+	h.cur++
+	return h.versions[h.cur], true
+}
+
+// Undo makes the previous version current and returns it.
+// The boolean value ok is false if there is no version to undo.
+func (h *History[T]) Undo() (v T, ok bool) {
+	if h.cur < 1 {
+		return v, false
+	}
+	h.cur--
+	return h.versions[h.cur], true
+}
+
+// Version returns the version number of the current value.
+func (h *History[T]) Version() int {
+	return h.first + h.cur
+}
+
+// New returns a History whose initial value is v, which is version 0.
+// At most limit versions are retained; a limit less than 1 means no limit.
+func New[T any](v T, limit int) *History[T] {
+	return &History[T]{versions: []T{v}, limit: limit}
+}
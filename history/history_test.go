@@ -0,0 +1,104 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package history_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/phelmkamp/immut/cowmaps"
+	"github.com/phelmkamp/immut/cowslices"
+	"github.com/phelmkamp/immut/history"
+	"github.com/phelmkamp/immut/romaps"
+)
+
+func Example() {
+	s := cowslices.CopyOnWrite([]string{"a"})
+	h := history.New(s, 0)
+
+	s = cowslices.Append(s, "b")
+	h.Commit(s)
+	s = cowslices.Append(s, "c")
+	h.Commit(s)
+
+	s, _ = h.Undo()
+	fmt.Println(s)
+	s, _ = h.Undo()
+	fmt.Println(s)
+	s, _ = h.Redo()
+	fmt.Println(s)
+	// Output: [a b]
+	// [a]
+	// [a b]
+}
+
+func TestHistory(t *testing.T) {
+	h := history.New(0, 0)
+	for i := 1; i <= 3; i++ {
+		if got := h.Commit(i); got != i {
+			t.Errorf("Commit() = %v, want %v", got, i)
+		}
+	}
+	if got, ok := h.Undo(); got != 2 || !ok {
+		t.Errorf("Undo() = %v %v, want %v %v", got, ok, 2, true)
+	}
+	if got := h.Version(); got != 2 {
+		t.Errorf("Version() = %v, want %v", got, 2)
+	}
+	if got, ok := h.At(3); got != 3 || !ok {
+		t.Errorf("At() = %v %v, want %v %v", got, ok, 3, true)
+	}
+
+	// commit discards redo
+	if got := h.Commit(4); got != 3 {
+		t.Errorf("Commit() = %v, want %v", got, 3)
+	}
+	if got, ok := h.Redo(); ok {
+		t.Errorf("Redo() = %v %v, want %v %v", got, ok, 0, false)
+	}
+	if got, ok := h.At(3); got != 4 || !ok {
+		t.Errorf("At() = %v %v, want %v %v", got, ok, 4, true)
+	}
+	if got := h.Len(); got != 4 {
+		t.Errorf("Len() = %v, want %v", got, 4)
+	}
+
+	for i := 0; i < 3; i++ {
+		h.Undo()
+	}
+	if got, ok := h.Undo(); ok {
+		t.Errorf("Undo() = %v %v, want %v %v", got, ok, 0, false)
+	}
+	if got := h.Current(); got != 0 {
+		t.Errorf("Current() = %v, want %v", got, 0)
+	}
+}
+
+func TestHistory_limit(t *testing.T) {
+	m := cowmaps.CopyOnWrite(map[string]int{})
+	h := history.New(m, 3)
+	for i := 1; i <= 5; i++ {
+		m.SetIndex(fmt.Sprint(i), i)
+		h.Commit(m)
+	}
+	if got := h.Len(); got != 3 {
+		t.Errorf("Len() = %v, want %v", got, 3)
+	}
+	if _, ok := h.At(2); ok {
+		t.Errorf("At(2) ok = %v, want %v", ok, false)
+	}
+	got, ok := h.At(3)
+	if want := romaps.Freeze(map[string]int{"1": 1, "2": 2, "3": 3}); !ok || !romaps.Equal(got.RO, want) {
+		t.Errorf("At(3) = %v %v, want %v %v", got, ok, want, true)
+	}
+	h.Undo()
+	h.Undo()
+	if got, ok := h.Undo(); ok {
+		t.Errorf("Undo() = %v %v, want %v %v", got, ok, nil, false)
+	}
+	if got := h.Version(); got != 3 {
+		t.Errorf("Version() = %v, want %v", got, 3)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package cowstats counts the clones performed by the copy-on-write packages.
+package cowstats
+
+import (
+	"expvar"
+	"sync"
+	"sync/atomic"
+)
+
+// Stat holds the counters for one operation.
+type Stat struct {
+	Clones    int64 // number of times the underlying value was cloned
+	Copied    int64 // number of elements copied into clones
+	FastPaths int64 // number of writes that avoided a clone
+}
+
+type counters struct {
+	clones, copied, fastPaths atomic.Int64
+}
+
+// Set is a set of counters, one per operation.
+// Counting is disabled until Enable is called.
+type Set struct {
+	name    string
+	ops     []string
+	enabled atomic.Bool
+	once    sync.Once
+	c       []counters
+}
+
+// New returns a Set for the named operations that is published via expvar under name once enabled.
+func New(name string, ops ...string) *Set {
+	return &Set{name: name, ops: ops, c: make([]counters, len(ops))}
+}
+
+// Clone counts a clone of n elements by the op'th operation.
+func (s *Set) Clone(op, n int) {
+	if s.enabled.Load() {
+		c := &s.c[op]
+		c.clones.Add(1)
+		c.copied.Add(int64(n))
+	}
+}
+
+// Enable enables or disables counting.
+func (s *Set) Enable(enabled bool) {
+	if enabled {
+		s.once.Do(func() {
+			expvar.Publish(s.name, expvar.Func(func() any { return s.Snapshot() }))
+		})
+	}
+	s.enabled.Store(enabled)
+}
+
+// FastPath counts a write by the op'th operation that avoided a clone.
+func (s *Set) FastPath(op int) {
+	if s.enabled.Load() {
+		s.c[op].fastPaths.Add(1)
+	}
+}
+
+// Reset sets all counters to zero.
+func (s *Set) Reset() {
+	for i := range s.c {
+		c := &s.c[i]
+		c.clones.Store(0)
+		c.copied.Store(0)
+		c.fastPaths.Store(0)
+	}
+}
+
+// Snapshot returns the current counters keyed by operation name,
+// omitting operations with no activity.
+func (s *Set) Snapshot() map[string]Stat {
+	m := make(map[string]Stat)
+	for i := range s.c {
+		c := &s.c[i]
+		st := Stat{Clones: c.clones.Load(), Copied: c.copied.Load(), FastPaths: c.fastPaths.Load()}
+		if st != (Stat{}) {
+			m[s.ops[i]] = st
+		}
+	}
+	return m
+}
@@ -0,0 +1,6 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package lens defines composable getters and setters useful with deeply nested immutable values.
+package lens
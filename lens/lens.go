@@ -0,0 +1,109 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package lens
+
+import (
+	"github.com/phelmkamp/immut/corptrs"
+	"github.com/phelmkamp/immut/cowmaps"
+	"github.com/phelmkamp/immut/cowslices"
+)
+
+// Lens focuses on a part A of a whole S.
+type Lens[S, A any] struct {
+	get func(S) A
+	set func(S, A) S
+}
+
+// Get returns the part of s in focus.
+func (l Lens[S, A]) Get(s S) A {
+	return l.get(s)
+}
+
+// Modify returns a copy of s with the part in focus replaced by f applied to it.
+func (l Lens[S, A]) Modify(s S, f func(A) A) S {
+	return l.set(s, f(l.get(s)))
+}
+
+// Set returns a copy of s with the part in focus replaced by a.
+// Only the values along the path to the part in focus are copied.
+func (l Lens[S, A]) Set(s S, a A) S {
+	return l.set(s, a)
+}
+
+// New returns a Lens defined by the given getter and setter.
+// set must not modify its argument; it must return a modified copy instead.
+func New[S, A any](get func(S) A, set func(S, A) S) Lens[S, A] {
+	return Lens[S, A]{get: get, set: set}
+}
+
+// Compose returns a Lens that focuses on the part B of the part A of a whole S.
+func Compose[S, A, B any](outer Lens[S, A], inner Lens[A, B]) Lens[S, B] {
+	return Lens[S, B]{
+		get: func(s S) B {
+			return inner.get(outer.get(s))
+		},
+		set: func(s S, b B) S {
+			return outer.set(s, inner.set(outer.get(s), b))
+		},
+	}
+}
+
+// Field returns a Lens that focuses on a field of the struct S.
+// set is called with a pointer to a shallow copy of the struct.
+func Field[S, A any](get func(S) A, set func(*S, A)) Lens[S, A] {
+	return Lens[S, A]{
+		get: get,
+		set: func(s S, a A) S {
+			set(&s, a)
+			return s
+		},
+	}
+}
+
+// Index returns a Lens that focuses on the i'th element of a copy-on-write slice.
+// Get and Set panic if i is out of range.
+func Index[E any](i int) Lens[cowslices.Slice[E], E] {
+	return Lens[cowslices.Slice[E], E]{
+		get: func(s cowslices.Slice[E]) E {
+			return s.RO.Index(i)
+		},
+		set: func(s cowslices.Slice[E], v E) cowslices.Slice[E] {
+			s.SetIndex(i, v)
+			return s
+		},
+	}
+}
+
+// Key returns a Lens that focuses on the value associated with k in a copy-on-write map.
+// Get returns the zero value if k is not present.
+func Key[K comparable, V any](k K) Lens[cowmaps.Map[K, V], V] {
+	return Lens[cowmaps.Map[K, V], V]{
+		get: func(m cowmaps.Map[K, V]) V {
+			v, _ := m.RO.Index(k)
+			return v
+		},
+		set: func(m cowmaps.Map[K, V], v V) cowmaps.Map[K, V] {
+			m.SetIndex(k, v)
+			return m
+		},
+	}
+}
+
+// Pointer returns a Lens that focuses on the value referenced by a read-only pointer.
+// Get returns the zero value if the pointer is nil.
+func Pointer[T any]() Lens[corptrs.Pointer[T], T] {
+	return Lens[corptrs.Pointer[T], T]{
+		get: func(p corptrs.Pointer[T]) T {
+			var v T
+			if p2 := p.Clone(); p2 != nil {
+				v = *p2
+			}
+			return v
+		},
+		set: func(_ corptrs.Pointer[T], v T) corptrs.Pointer[T] {
+			return corptrs.Freeze(&v)
+		},
+	}
+}
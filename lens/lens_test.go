@@ -0,0 +1,123 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package lens_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/phelmkamp/immut/corptrs"
+	"github.com/phelmkamp/immut/cowmaps"
+	"github.com/phelmkamp/immut/cowslices"
+	"github.com/phelmkamp/immut/lens"
+	"github.com/phelmkamp/immut/romaps"
+)
+
+type service struct {
+	Name  string
+	Ports cowslices.Slice[int]
+}
+
+type config struct {
+	Services cowmaps.Map[string, service]
+}
+
+var (
+	services = lens.Field(
+		func(c config) cowmaps.Map[string, service] { return c.Services },
+		func(c *config, m cowmaps.Map[string, service]) { c.Services = m },
+	)
+	ports = lens.Field(
+		func(s service) cowslices.Slice[int] { return s.Ports },
+		func(s *service, p cowslices.Slice[int]) { s.Ports = p },
+	)
+)
+
+func newConfig() config {
+	return config{
+		Services: cowmaps.CopyOnWrite(map[string]service{
+			"api": {Name: "api", Ports: cowslices.CopyOnWrite([]int{80, 443, 8080})},
+			"db":  {Name: "db", Ports: cowslices.CopyOnWrite([]int{5432})},
+		}),
+	}
+}
+
+func Example() {
+	cfg := newConfig()
+	port := lens.Compose(
+		lens.Compose(services, lens.Key[string, service]("api")),
+		lens.Compose(ports, lens.Index[int](2)),
+	)
+	cfg2 := port.Set(cfg, 9090)
+	fmt.Println(port.Get(cfg), port.Get(cfg2))
+	// Output: 8080 9090
+}
+
+func TestCompose_Set(t *testing.T) {
+	cfg := newConfig()
+	api := lens.Compose(services, lens.Key[string, service]("api"))
+	port := lens.Compose(api, lens.Compose(ports, lens.Index[int](0)))
+
+	cfg2 := port.Set(cfg, 81)
+	if got := port.Get(cfg2); got != 81 {
+		t.Errorf("Get() = %v, want %v", got, 81)
+	}
+	if got := port.Get(cfg); got != 80 {
+		t.Errorf("Get() on original = %v, want %v", got, 80)
+	}
+
+	// nodes off the path are unchanged
+	db, _ := cfg.Services.RO.Index("db")
+	db2, _ := cfg2.Services.RO.Index("db")
+	if !reflect.DeepEqual(db, db2) {
+		t.Errorf("db = %v, want %v", db2, db)
+	}
+	if api.Get(cfg2).Name != "api" {
+		t.Errorf("Name = %v, want %v", api.Get(cfg2).Name, "api")
+	}
+}
+
+func TestLens_Modify(t *testing.T) {
+	l := lens.Key[string, int]("foo")
+	m := cowmaps.CopyOnWrite(map[string]int{"foo": 1})
+	m2 := l.Modify(m, func(v int) int { return v + 1 })
+	if !romaps.Equal(m2.RO, romaps.Freeze(map[string]int{"foo": 2})) {
+		t.Errorf("Modify() = %v, want %v", m2, map[string]int{"foo": 2})
+	}
+	if !romaps.Equal(m.RO, romaps.Freeze(map[string]int{"foo": 1})) {
+		t.Errorf("original after Modify() = %v, want %v", m, map[string]int{"foo": 1})
+	}
+}
+
+func TestKey_missing(t *testing.T) {
+	l := lens.Key[string, int]("bar")
+	m := cowmaps.CopyOnWrite(map[string]int{"foo": 1})
+	if got := l.Get(m); got != 0 {
+		t.Errorf("Get() = %v, want %v", got, 0)
+	}
+	if got := l.Get(l.Set(m, 2)); got != 2 {
+		t.Errorf("Get() = %v, want %v", got, 2)
+	}
+}
+
+func TestPointer(t *testing.T) {
+	type point struct{ X, Y int }
+	x := lens.Compose(lens.Pointer[point](), lens.Field(
+		func(p point) int { return p.X },
+		func(p *point, v int) { p.X = v },
+	))
+	p := corptrs.Freeze(&point{1, 2})
+	p2 := x.Set(p, 3)
+	if got := *p2.Clone(); got != (point{3, 2}) {
+		t.Errorf("Set() = %v, want %v", got, point{3, 2})
+	}
+	if got := *p.Clone(); got != (point{1, 2}) {
+		t.Errorf("original after Set() = %v, want %v", got, point{1, 2})
+	}
+	if got := x.Get(corptrs.Freeze[point](nil)); got != 0 {
+		t.Errorf("Get() = %v, want %v", got, 0)
+	}
+}
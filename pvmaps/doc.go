@@ -0,0 +1,7 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package pvmaps defines various copy-on-write functions useful with persistent hash maps of any type.
+// Unlike cowmaps, writes are O(log32 n) and share unchanged structure with previous versions.
+package pvmaps
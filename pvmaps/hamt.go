@@ -0,0 +1,207 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pvmaps
+
+import (
+	"math/bits"
+)
+
+const (
+	bitsPerLevel = 5
+	levelMask    = 1<<bitsPerLevel - 1
+	hashBits     = 64
+)
+
+// entry is a key/value pair along with the hash of the key.
+type entry[K comparable, V any] struct {
+	hash uint64
+	key  K
+	val  V
+}
+
+// slot is either a sub-trie or an entry.
+type slot[K comparable, V any] struct {
+	node        *node[K, V]
+	entry[K, V] // valid if node is nil
+}
+
+// node is a node of a hash array mapped trie.
+// Nodes are never modified after construction; writes copy the path from the root.
+type node[K comparable, V any] struct {
+	bitmap uint32        // bit i is set if the slot for hash fragment i is present
+	slots  []slot[K, V]  // present slots ordered by hash fragment
+	coll   []entry[K, V] // entries whose hashes are equal, once the hash is exhausted
+}
+
+// fragment returns the bit of n's bitmap for h at shift and the index of its slot.
+func (n *node[K, V]) fragment(h uint64, shift uint) (bit uint32, pos int) {
+	bit = 1 << ((h >> shift) & levelMask)
+	return bit, bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+// single returns the only entry of n, if n has exactly one entry and no sub-tries.
+func (n *node[K, V]) single() (e entry[K, V], ok bool) {
+	switch {
+	case len(n.coll) == 1:
+		return n.coll[0], true
+	case len(n.slots) == 1 && n.slots[0].node == nil:
+		return n.slots[0].entry, true
+	}
+	return e, false
+}
+
+func get[K comparable, V any](n *node[K, V], h uint64, k K) (v V, ok bool) {
+	for shift := uint(0); n != nil; shift += bitsPerLevel {
+		if shift >= hashBits {
+			for _, e := range n.coll {
+				if e.key == k {
+					return e.val, true
+				}
+			}
+			return v, false
+		}
+		bit, pos := n.fragment(h, shift)
+		if n.bitmap&bit == 0 {
+			return v, false
+		}
+		s := &n.slots[pos]
+		if s.node == nil {
+			if s.hash == h && s.key == k {
+				return s.val, true
+			}
+			return v, false
+		}
+		n = s.node
+	}
+	return v, false
+}
+
+// set returns a copy of n in which e is present and whether e.key was added.
+func set[K comparable, V any](n *node[K, V], e entry[K, V], shift uint) (*node[K, V], bool) {
+	if shift >= hashBits {
+		if n == nil {
+			return &node[K, V]{coll: []entry[K, V]{e}}, true
+		}
+		for i := range n.coll {
+			if n.coll[i].key == e.key {
+				coll := append([]entry[K, V](nil), n.coll...)
+				coll[i] = e
+				return &node[K, V]{coll: coll}, false
+			}
+		}
+		coll := append(append(make([]entry[K, V], 0, len(n.coll)+1), n.coll...), e)
+		return &node[K, V]{coll: coll}, true
+	}
+	if n == nil {
+		n = &node[K, V]{}
+	}
+	bit, pos := n.fragment(e.hash, shift)
+	if n.bitmap&bit == 0 {
+		slots := make([]slot[K, V], len(n.slots)+1)
+		copy(slots, n.slots[:pos])
+		slots[pos] = slot[K, V]{entry: e}
+		copy(slots[pos+1:], n.slots[pos:])
+		return &node[K, V]{bitmap: n.bitmap | bit, slots: slots}, true
+	}
+	s := n.slots[pos]
+	var added bool
+	switch {
+	case s.node != nil:
+		s.node, added = set(s.node, e, shift+bitsPerLevel)
+	case s.hash == e.hash && s.key == e.key:
+		s.entry = e
+	default:
+		// Push the existing entry down into a new sub-trie.
+		s.node, _ = set(nil, s.entry, shift+bitsPerLevel)
+		s.node, added = set(s.node, e, shift+bitsPerLevel)
+		s.entry = entry[K, V]{}
+	}
+	return n.withSlot(pos, s), added
+}
+
+// del returns a copy of n without k, or nil if it would be empty, and the deleted value.
+func del[K comparable, V any](n *node[K, V], h uint64, k K, shift uint) (n2 *node[K, V], v V, ok bool) {
+	if n == nil {
+		return nil, v, false
+	}
+	if shift >= hashBits {
+		for i := range n.coll {
+			if n.coll[i].key == k {
+				if len(n.coll) == 1 {
+					return nil, n.coll[i].val, true
+				}
+				coll := make([]entry[K, V], 0, len(n.coll)-1)
+				coll = append(append(coll, n.coll[:i]...), n.coll[i+1:]...)
+				return &node[K, V]{coll: coll}, n.coll[i].val, true
+			}
+		}
+		return n, v, false
+	}
+	bit, pos := n.fragment(h, shift)
+	if n.bitmap&bit == 0 {
+		return n, v, false
+	}
+	s := n.slots[pos]
+	if s.node == nil {
+		if s.hash != h || s.key != k {
+			return n, v, false
+		}
+		return n.withoutSlot(pos, bit), s.val, true
+	}
+	child, v, ok := del(s.node, h, k, shift+bitsPerLevel)
+	if !ok {
+		return n, v, false
+	}
+	if child == nil {
+		return n.withoutSlot(pos, bit), v, true
+	}
+	if e, single := child.single(); single {
+		// Keep the trie canonical by pulling a lone entry up.
+		s = slot[K, V]{entry: e}
+	} else {
+		s.node = child
+	}
+	return n.withSlot(pos, s), v, true
+}
+
+// withSlot returns a copy of n with the slot at pos replaced by s.
+func (n *node[K, V]) withSlot(pos int, s slot[K, V]) *node[K, V] {
+	slots := append([]slot[K, V](nil), n.slots...)
+	slots[pos] = s
+	return &node[K, V]{bitmap: n.bitmap, slots: slots}
+}
+
+// withoutSlot returns a copy of n without the slot at pos, or nil if it would be empty.
+func (n *node[K, V]) withoutSlot(pos int, bit uint32) *node[K, V] {
+	if len(n.slots) == 1 {
+		return nil
+	}
+	slots := make([]slot[K, V], 0, len(n.slots)-1)
+	slots = append(append(slots, n.slots[:pos]...), n.slots[pos+1:]...)
+	return &node[K, V]{bitmap: n.bitmap &^ bit, slots: slots}
+}
+
+// do calls f on every entry of n, stopping if f returns false.
+func do[K comparable, V any](n *node[K, V], f func(K, V) bool) bool {
+	if n == nil {
+		return true
+	}
+	for _, e := range n.coll {
+		if !f(e.key, e.val) {
+			return false
+		}
+	}
+	for i := range n.slots {
+		s := &n.slots[i]
+		if s.node != nil {
+			if !do(s.node, f) {
+				return false
+			}
+		} else if !f(s.key, s.val) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pvmaps
+
+import (
+	"testing"
+)
+
+func Test_setIndex_shares(t *testing.T) {
+	m1 := New[int, int](HashInt[int])
+	for i := 0; i < 1_000; i++ {
+		m1.SetIndex(i, i)
+	}
+	m2 := m1
+	m2.SetIndex(0, -1)
+	if v, _ := m1.Index(0); v != 0 {
+		t.Fatalf("Index(0) = %v, want %v", v, 0)
+	}
+	// only the slot on the path to 0 is copied
+	_, pos := m1.root.fragment(HashInt(0), 0)
+	shared := 0
+	for i := range m1.root.slots {
+		if i != pos && m1.root.slots[i].node == m2.root.slots[i].node {
+			shared++
+		}
+	}
+	if want := len(m1.root.slots) - 1; shared != want {
+		t.Errorf("shared sub-tries = %v, want %v", shared, want)
+	}
+}
+
+func Test_delete_canonical(t *testing.T) {
+	m := New[int, int](func(k int) uint64 { return uint64(k) << 40 })
+	for i := 0; i < 100; i++ {
+		m.SetIndex(i, i)
+	}
+	for i := 1; i < 100; i++ {
+		m.Delete(i)
+	}
+	if e, ok := m.root.single(); !ok || e.key != 0 {
+		t.Errorf("root.single() = %v %v, want %v %v", e.key, ok, 0, true)
+	}
+}
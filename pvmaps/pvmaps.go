@@ -0,0 +1,152 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pvmaps
+
+import (
+	"fmt"
+	"hash/maphash"
+
+	"github.com/phelmkamp/immut/romaps"
+	"golang.org/x/exp/constraints"
+)
+
+// Map wraps a copy-on-write persistent hash map, implemented as a hash array mapped trie.
+// The zero value is an empty Map that cannot be written to; use New or CopyOnWrite instead.
+type Map[K comparable, V any] struct {
+	root *node[K, V]
+	len  int
+	hash func(K) uint64
+}
+
+// Delete deletes the element with the specified key from the map.
+// If there is no such element, delete is a no-op.
+// It is O(log32 n) and only the nodes along the path to k are copied.
+func (m *Map[K, V]) Delete(k K) (v V, ok bool) {
+	if m.root == nil {
+		return
+	}
+	if m.root, v, ok = del(m.root, m.hash(k), k, 0); ok {
+		m.len--
+	}
+	return
+}
+
+// Do calls f on every key/value pair in an indeterminate order, stopping if f returns false.
+func (m Map[K, V]) Do(f func(k K, v V) bool) {
+	do(m.root, f)
+}
+
+// Index returns the element associated with k.
+// The boolean value ok is true if the map contains an element with the specified key.
+// It is O(log32 n).
+func (m Map[K, V]) Index(k K) (v V, ok bool) {
+	if m.root == nil {
+		return
+	}
+	return get(m.root, m.hash(k), k)
+}
+
+// Len returns the number of elements in the map.
+func (m Map[K, V]) Len() int {
+	return m.len
+}
+
+// RO returns a read-only map of the elements for use with the romaps functions.
+// Note: The elements are copied into a new map, so it is O(n).
+func (m Map[K, V]) RO() romaps.Map[K, V] {
+	return romaps.Freeze(Clone(m))
+}
+
+// SetIndex sets the element associated with k to v.
+// It is O(log32 n) and only the nodes along the path to k are copied.
+func (m *Map[K, V]) SetIndex(k K, v V) {
+	if m.hash == nil {
+		panic("pvmaps: SetIndex on Map without hash function")
+	}
+	var added bool
+	if m.root, added = set(m.root, entry[K, V]{hash: m.hash(k), key: k, val: v}, 0); added {
+		m.len++
+	}
+}
+
+// String returns the map formatted as a string.
+func (m Map[K, V]) String() string {
+	return fmt.Sprint(Clone(m))
+}
+
+// New returns an empty Map that uses hash to hash keys.
+// Keys that are equal must have equal hashes.
+func New[K comparable, V any](hash func(K) uint64) Map[K, V] {
+	return Map[K, V]{hash: hash}
+}
+
+// CopyOnWrite returns a copy-on-write persistent hash map of the elements of m that uses hash to hash keys.
+// Note: The elements are copied, so it is O(n).
+func CopyOnWrite[K comparable, V any](m map[K]V, hash func(K) uint64) Map[K, V] {
+	m2 := New[K, V](hash)
+	for k, v := range m {
+		m2.SetIndex(k, v)
+	}
+	return m2
+}
+
+// Clear removes all entries from m, leaving it empty.
+func Clear[K comparable, V any](m *Map[K, V]) {
+	m.root, m.len = nil, 0
+}
+
+// Clone returns a mutable copy of the map.
+// The elements are copied using assignment, so this is a shallow clone.
+func Clone[K comparable, V any](m Map[K, V]) map[K]V {
+	m2 := make(map[K]V, m.Len())
+	m.Do(func(k K, v V) bool {
+		m2[k] = v
+		return true
+	})
+	return m2
+}
+
+// Copy copies all key/value pairs in src adding them to dst.
+// When a key in src is already present in dst,
+// the value in dst will be overwritten by the value associated
+// with the key in src.
+// It is O(len(src) log32 n).
+func Copy[K comparable, V any](dst *Map[K, V], src romaps.Map[K, V]) {
+	src.Do(func(k K, v V) bool {
+		dst.SetIndex(k, v)
+		return true
+	})
+}
+
+// DeleteFunc deletes any key/value pairs from m for which del returns true.
+func DeleteFunc[K comparable, V any](m *Map[K, V], del func(K, V) bool) {
+	var keys []K
+	m.Do(func(k K, v V) bool {
+		if del(k, v) {
+			keys = append(keys, k)
+		}
+		return true
+	})
+	for _, k := range keys {
+		m.Delete(k)
+	}
+}
+
+var seed = maphash.MakeSeed()
+
+// HashInt returns a hash of the integer k suitable for New.
+func HashInt[K constraints.Integer](k K) uint64 {
+	// splitmix64 finalizer
+	x := uint64(k)
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// HashString returns a hash of the string k suitable for New.
+// Hashes differ between processes.
+func HashString(k string) uint64 {
+	return maphash.String(seed, k)
+}
@@ -0,0 +1,50 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pvmaps_test
+
+import (
+	"testing"
+
+	"github.com/phelmkamp/immut/cowmaps"
+	"github.com/phelmkamp/immut/pvmaps"
+)
+
+const N = 100_000
+
+func BenchmarkSetIndex(b *testing.B) {
+	m := pvmaps.New[int, int](pvmaps.HashInt[int])
+	for i := 0; i < N; i++ {
+		m.SetIndex(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m2 := m
+		m2.SetIndex(i%N, -i)
+	}
+}
+
+func BenchmarkSetIndex_cowmaps(b *testing.B) {
+	m := make(map[int]int, N)
+	for i := 0; i < N; i++ {
+		m[i] = i
+	}
+	m2 := cowmaps.CopyOnWrite(m)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m3 := m2
+		m3.SetIndex(i%N, -i)
+	}
+}
+
+func BenchmarkIndex(b *testing.B) {
+	m := pvmaps.New[int, int](pvmaps.HashInt[int])
+	for i := 0; i < N; i++ {
+		m.SetIndex(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Index(i % N)
+	}
+}
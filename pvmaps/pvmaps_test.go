@@ -0,0 +1,123 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pvmaps_test
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/phelmkamp/immut/pvmaps"
+	"github.com/phelmkamp/immut/romaps"
+)
+
+func Example() {
+	m1 := pvmaps.CopyOnWrite(map[string]int{"foo": 42, "bar": 7}, pvmaps.HashString)
+	m2 := m1
+	m2.SetIndex("baz", 1)
+	m2.Delete("foo")
+	fmt.Println(m1)
+	fmt.Println(m2)
+	// Output: map[bar:7 foo:42]
+	// map[bar:7 baz:1]
+}
+
+func testMap(t *testing.T, hash func(int) uint64) {
+	r := rand.New(rand.NewSource(1))
+	m := pvmaps.New[int, int](hash)
+	want := make(map[int]int)
+	var versions []pvmaps.Map[int, int]
+	var wants []map[int]int
+	for i := 0; i < 5_000; i++ {
+		k := r.Intn(1_000)
+		if r.Intn(3) == 0 {
+			got, gotOK := m.Delete(k)
+			v, ok := want[k]
+			delete(want, k)
+			if got != v || gotOK != ok {
+				t.Fatalf("Delete(%v) = %v %v, want %v %v", k, got, gotOK, v, ok)
+			}
+		} else {
+			m.SetIndex(k, i)
+			want[k] = i
+		}
+		if m.Len() != len(want) {
+			t.Fatalf("Len() = %v, want %v", m.Len(), len(want))
+		}
+		if i%500 == 0 {
+			versions = append(versions, m)
+			wants = append(wants, pvmaps.Clone(m))
+		}
+	}
+	if got := pvmaps.Clone(m); !reflect.DeepEqual(got, want) {
+		t.Errorf("Clone() = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got, ok := m.Index(k); got != v || !ok {
+			t.Fatalf("Index(%v) = %v %v, want %v %v", k, got, ok, v, true)
+		}
+	}
+	// previous versions are unaffected
+	for i, v := range versions {
+		if got := pvmaps.Clone(v); !reflect.DeepEqual(got, wants[i]) {
+			t.Errorf("version %d = %v, want %v", i, got, wants[i])
+		}
+	}
+	for k := range want {
+		m.Delete(k)
+	}
+	if got := m.Len(); got != 0 {
+		t.Errorf("Len() = %v, want %v", got, 0)
+	}
+}
+
+func TestMap(t *testing.T) {
+	testMap(t, pvmaps.HashInt[int])
+}
+
+func TestMap_collisions(t *testing.T) {
+	testMap(t, func(k int) uint64 { return uint64(k % 7) })
+}
+
+func TestMap_zero(t *testing.T) {
+	var m pvmaps.Map[string, int]
+	if v, ok := m.Index("a"); v != 0 || ok {
+		t.Errorf("Index() = %v %v, want %v %v", v, ok, 0, false)
+	}
+	if v, ok := m.Delete("a"); v != 0 || ok {
+		t.Errorf("Delete() = %v %v, want %v %v", v, ok, 0, false)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("SetIndex() did not panic")
+		}
+	}()
+	m.SetIndex("a", 1)
+}
+
+func TestCopy(t *testing.T) {
+	m := pvmaps.CopyOnWrite(map[string]int{"a": 1, "b": 2}, pvmaps.HashString)
+	pvmaps.Copy(&m, romaps.Freeze(map[string]int{"b": 3, "c": 4}))
+	if got, want := m.RO(), romaps.Freeze(map[string]int{"a": 1, "b": 3, "c": 4}); !romaps.Equal(got, want) {
+		t.Errorf("Copy() = %v, want %v", got, want)
+	}
+}
+
+func TestDeleteFunc(t *testing.T) {
+	m := pvmaps.CopyOnWrite(map[int]int{1: 1, 2: 2, 3: 3, 4: 4}, pvmaps.HashInt[int])
+	m2 := m
+	pvmaps.DeleteFunc(&m2, func(k, v int) bool { return v%2 == 0 })
+	if got, want := pvmaps.Clone(m2), map[int]int{1: 1, 3: 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DeleteFunc() = %v, want %v", got, want)
+	}
+	if got := m.Len(); got != 4 {
+		t.Errorf("Len() = %v, want %v", got, 4)
+	}
+	pvmaps.Clear(&m2)
+	if got := m2.Len(); got != 0 {
+		t.Errorf("Len() after Clear() = %v, want %v", got, 0)
+	}
+}
@@ -0,0 +1,7 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package pvslices defines various copy-on-write functions useful with persistent vectors of any type.
+// Unlike cowslices, writes are O(log n) and share unchanged structure with previous versions.
+package pvslices
@@ -0,0 +1,126 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pvslices
+
+import (
+	"fmt"
+
+	"github.com/phelmkamp/immut/roslices"
+)
+
+// Slice wraps a copy-on-write persistent vector.
+// Elements are stored in chunks of up to 32 that are shared between versions.
+// The zero value is an empty Slice.
+type Slice[E any] struct {
+	root *node[E]
+}
+
+// Do calls f on every element in order, stopping if f returns false.
+func (s Slice[E]) Do(f func(i int, v E) bool) {
+	do(s.root, 0, f)
+}
+
+// Index returns the i'th element.
+// It is O(log n).
+func (s Slice[E]) Index(i int) E {
+	checkIndex(i, s.Len())
+	return index(s.root, i)
+}
+
+// Len returns the length.
+func (s Slice[E]) Len() int {
+	return size(s.root)
+}
+
+// RO returns a read-only slice of the elements for use with the roslices functions.
+// Note: Unless the elements fit in a single chunk, the first call for a version copies them into a new slice,
+// so it is O(n). The copy is retained by that version, so subsequent calls are O(1).
+// Use Do and Index to read without copying.
+func (s Slice[E]) RO() roslices.Slice[E] {
+	return roslices.Freeze(flatten(s.root))
+}
+
+// SetIndex sets the element at i to v.
+// It is O(log n) and only the nodes along the path to i and the chunk containing i are copied.
+func (s *Slice[E]) SetIndex(i int, v E) {
+	checkIndex(i, s.Len())
+	s.root = setIndex(s.root, i, v)
+}
+
+// Slice returns s[i:j].
+// It panics if the indexes are out of bounds.
+func (s Slice[E]) Slice(i, j int) Slice[E] {
+	checkRange(i, j, s.Len())
+	r, _ := split(s.root, j)
+	_, r = split(r, i)
+	return Slice[E]{root: r}
+}
+
+// String returns the elements formatted as a string.
+func (s Slice[E]) String() string {
+	return fmt.Sprint(Clone(s))
+}
+
+// CopyOnWrite returns a copy-on-write persistent vector of the elements of s.
+// Note: The elements are copied, so it is O(n).
+func CopyOnWrite[E any](s []E) Slice[E] {
+	return Slice[E]{root: build(s)}
+}
+
+// Append appends the values v... to the end of s, returning the modified slice.
+// It is O(log n + len(v)).
+func Append[E any](s Slice[E], v ...E) Slice[E] {
+	s.root = concat(s.root, build(v))
+	return s
+}
+
+// Clone returns a mutable copy of the slice.
+// The elements are copied using assignment, so this is a shallow clone.
+func Clone[E any](s Slice[E]) []E {
+	if s.root == nil {
+		return nil
+	}
+	s2 := make([]E, 0, s.Len())
+	s.Do(func(_ int, v E) bool {
+		s2 = append(s2, v)
+		return true
+	})
+	return s2
+}
+
+// Delete removes the elements s[i:j] from s, returning the modified slice.
+// Delete panics if s[i:j] is not a valid slice of s.
+// It is O(log n).
+func Delete[E any](s Slice[E], i, j int) Slice[E] {
+	checkRange(i, j, s.Len())
+	l, r := split(s.root, j)
+	l, _ = split(l, i)
+	s.root = concat(l, r)
+	return s
+}
+
+// Insert inserts the values v... into s at index i,
+// returning the modified slice.
+// In the returned slice r, r[i] == v[0].
+// Insert panics if i is out of range.
+// It is O(log n + len(v)).
+func Insert[E any](s Slice[E], i int, v ...E) Slice[E] {
+	checkRange(i, i, s.Len())
+	l, r := split(s.root, i)
+	s.root = concat(concat(l, build(v)), r)
+	return s
+}
+
+func checkIndex(i, n int) {
+	if i < 0 || i >= n {
+		panic(fmt.Sprintf("pvslices: index out of range [%d] with length %d", i, n))
+	}
+}
+
+func checkRange(i, j, n int) {
+	if i < 0 || j > n || i > j {
+		panic(fmt.Sprintf("pvslices: slice bounds out of range [%d:%d] with length %d", i, j, n))
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pvslices
+
+import (
+	"testing"
+
+	"github.com/phelmkamp/immut/cowslices"
+)
+
+const N = 100_000
+
+func BenchmarkSetIndex(b *testing.B) {
+	s := CopyOnWrite(make([]int, N))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.SetIndex(i%N, i)
+	}
+}
+
+func BenchmarkCowslicesSetIndex(b *testing.B) {
+	s := cowslices.CopyOnWrite(make([]int, N))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.SetIndex(i%N, i)
+	}
+}
+
+func BenchmarkInsert(b *testing.B) {
+	s := CopyOnWrite(make([]int, N))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s = Insert(s, N/2, i)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pvslices_test
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/phelmkamp/immut/pvslices"
+	"github.com/phelmkamp/immut/roslices"
+	"golang.org/x/exp/slices"
+)
+
+func Example() {
+	s1 := pvslices.CopyOnWrite([]int{1, 2, 3})
+	s2 := pvslices.Insert(s1, 1, 4, 5)
+	s2.SetIndex(0, 0)
+	s2 = pvslices.Append(s2, 6)
+	fmt.Println(s1, s2)
+	fmt.Println(roslices.Contains(s2.RO(), 5))
+	// Output: [1 2 3] [0 4 5 2 3 6]
+	// true
+}
+
+func TestSlice_random(t *testing.T) {
+	rand.Seed(42)
+	var want []int
+	var got pvslices.Slice[int]
+	versions := []struct {
+		want []int
+		got  pvslices.Slice[int]
+	}{}
+	for i := 0; i < 2_000; i++ {
+		switch op := rand.Intn(4); {
+		case op == 0 || len(want) == 0:
+			v := []int{i, -i}
+			want = append(slices.Clone(want), v...)
+			got = pvslices.Append(got, v...)
+		case op == 1:
+			j := rand.Intn(len(want) + 1)
+			want = slices.Insert(slices.Clone(want), j, i)
+			got = pvslices.Insert(got, j, i)
+		case op == 2:
+			j := rand.Intn(len(want))
+			k := j + rand.Intn(len(want)-j+1)
+			want = slices.Delete(slices.Clone(want), j, k)
+			got = pvslices.Delete(got, j, k)
+		case op == 3:
+			j := rand.Intn(len(want))
+			want = slices.Clone(want)
+			want[j] = i
+			got.SetIndex(j, i)
+		}
+		versions = append(versions, struct {
+			want []int
+			got  pvslices.Slice[int]
+		}{want, got})
+	}
+	for i, v := range versions {
+		if got := pvslices.Clone(v.got); !slices.Equal(got, v.want) || v.got.Len() != len(v.want) {
+			t.Fatalf("version %d = %v, want %v", i, got, v.want)
+		}
+	}
+}
+
+func TestSlice_Index(t *testing.T) {
+	s := pvslices.CopyOnWrite([]string{"a", "b", "c"})
+	for i, want := range []string{"a", "b", "c"} {
+		if got := s.Index(i); got != want {
+			t.Errorf("Index(%d) = %v, want %v", i, got, want)
+		}
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Index(3) did not panic")
+		}
+	}()
+	s.Index(3)
+}
+
+func TestSlice_Slice(t *testing.T) {
+	s := pvslices.CopyOnWrite([]int{0, 1, 2, 3, 4})
+	if got, want := pvslices.Clone(s.Slice(1, 4)), []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Slice() = %v, want %v", got, want)
+	}
+	if got := s.Slice(2, 2).Len(); got != 0 {
+		t.Errorf("Slice().Len() = %v, want %v", got, 0)
+	}
+}
+
+func TestSlice_Do(t *testing.T) {
+	s := pvslices.CopyOnWrite([]int{0, 1, 2, 3, 4})
+	var got []int
+	s.Do(func(i, v int) bool {
+		if i != v {
+			t.Errorf("Do() i = %v, v = %v", i, v)
+		}
+		got = append(got, v)
+		return v < 2
+	})
+	if want := []int{0, 1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Do() = %v, want %v", got, want)
+	}
+}
+
+func TestSlice_String(t *testing.T) {
+	tests := []struct {
+		name string
+		s    pvslices.Slice[int]
+		want string
+	}{
+		{"zero", pvslices.Slice[int]{}, "[]"},
+		{"nil", pvslices.CopyOnWrite[int](nil), "[]"},
+		{"one", pvslices.CopyOnWrite([]int{1}), "[1]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.String(); got != tt.want {
+				t.Errorf("String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pvslices
+
+import "sync/atomic"
+
+// chunkSize is the maximum number of elements in a leaf.
+const chunkSize = 32
+
+// node is a node of an immutable AVL tree of chunks ordered by position.
+// Elements are stored in leaves of up to chunkSize elements; internal nodes have exactly two children.
+// Nodes and their chunks are never modified after construction so they can be shared between versions.
+type node[E any] struct {
+	left, right *node[E] // nil for a leaf
+	elems       []E      // elements of a leaf, with no spare capacity
+	size        int      // number of elements in the subtree
+	height      int      // 1 for a leaf
+
+	flat atomic.Pointer[[]E] // elements of the subtree, once flattened by RO
+}
+
+func (n *node[E]) leaf() bool {
+	return n.left == nil
+}
+
+func size[E any](n *node[E]) int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+func height[E any](n *node[E]) int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+// mkLeaf returns a leaf that takes ownership of elems.
+func mkLeaf[E any](elems []E) *node[E] {
+	return &node[E]{elems: elems[:len(elems):len(elems)], size: len(elems), height: 1}
+}
+
+func mk[E any](l, r *node[E]) *node[E] {
+	h := height(l)
+	if hr := height(r); hr > h {
+		h = hr
+	}
+	return &node[E]{left: l, right: r, size: size(l) + size(r), height: h + 1}
+}
+
+func rotateLeft[E any](n *node[E]) *node[E] {
+	return mk(mk(n.left, n.right.left), n.right.right)
+}
+
+func rotateRight[E any](n *node[E]) *node[E] {
+	return mk(n.left.left, mk(n.left.right, n.right))
+}
+
+// rebalance returns mk(l, r), rotating if their heights differ by 2.
+func rebalance[E any](l, r *node[E]) *node[E] {
+	switch {
+	case height(l) > height(r)+1:
+		if height(l.left) < height(l.right) {
+			l = rotateLeft(l)
+		}
+		return mk(l.left, mk(l.right, r))
+	case height(r) > height(l)+1:
+		if height(r.right) < height(r.left) {
+			r = rotateRight(r)
+		}
+		return mk(mk(l, r.left), r.right)
+	}
+	return mk(l, r)
+}
+
+// build returns a balanced tree of a copy of the elements of s.
+func build[E any](s []E) *node[E] {
+	if len(s) == 0 {
+		return nil
+	}
+	leaves := make([]*node[E], 0, (len(s)+chunkSize-1)/chunkSize)
+	for i := 0; i < len(s); i += chunkSize {
+		j := i + chunkSize
+		if j > len(s) {
+			j = len(s)
+		}
+		leaves = append(leaves, mkLeaf(append([]E(nil), s[i:j]...)))
+	}
+	return buildLeaves(leaves)
+}
+
+func buildLeaves[E any](leaves []*node[E]) *node[E] {
+	if len(leaves) == 1 {
+		return leaves[0]
+	}
+	m := len(leaves) / 2
+	return mk(buildLeaves(leaves[:m]), buildLeaves(leaves[m:]))
+}
+
+// index returns the i'th element of n.
+func index[E any](n *node[E], i int) E {
+	for !n.leaf() {
+		if ls := n.left.size; i < ls {
+			n = n.left
+		} else {
+			n, i = n.right, i-ls
+		}
+	}
+	return n.elems[i]
+}
+
+// setIndex returns a copy of n with the i'th element set to v.
+// Only the nodes along the path to i and the chunk containing it are copied.
+func setIndex[E any](n *node[E], i int, v E) *node[E] {
+	if n.leaf() {
+		elems := append([]E(nil), n.elems...)
+		elems[i] = v
+		return mkLeaf(elems)
+	}
+	if ls := n.left.size; i >= ls {
+		return mk(n.left, setIndex(n.right, i-ls, v))
+	}
+	return mk(setIndex(n.left, i, v), n.right)
+}
+
+// join returns the concatenation of l and r.
+// It is O(|height(l) - height(r)|).
+func join[E any](l, r *node[E]) *node[E] {
+	switch hl, hr := height(l), height(r); {
+	case l == nil:
+		return r
+	case r == nil:
+		return l
+	case hl > hr+1:
+		return rebalance(l.left, join(l.right, r))
+	case hr > hl+1:
+		return rebalance(join(l, r.left), r.right)
+	default:
+		return mk(l, r)
+	}
+}
+
+// concat returns the concatenation of l and r.
+// If the chunks at the seam fit in one, they are merged so that repeated edits do not fragment the tree.
+func concat[E any](l, r *node[E]) *node[E] {
+	if l == nil {
+		return r
+	}
+	if r == nil {
+		return l
+	}
+	if lastLeaf(l).size+firstLeaf(r).size > chunkSize {
+		return join(l, r)
+	}
+	l, a := splitLast(l)
+	r, b := splitFirst(r)
+	elems := make([]E, 0, len(a)+len(b))
+	elems = append(append(elems, a...), b...)
+	return join(join(l, mkLeaf(elems)), r)
+}
+
+func firstLeaf[E any](n *node[E]) *node[E] {
+	for !n.leaf() {
+		n = n.left
+	}
+	return n
+}
+
+func lastLeaf[E any](n *node[E]) *node[E] {
+	for !n.leaf() {
+		n = n.right
+	}
+	return n
+}
+
+// split returns the first i elements of n and the remaining elements.
+// A chunk that straddles i is shared by both halves.
+func split[E any](n *node[E], i int) (*node[E], *node[E]) {
+	switch {
+	case n == nil || i == 0:
+		return nil, n
+	case i == n.size:
+		return n, nil
+	case n.leaf():
+		return mkLeaf(n.elems[:i]), mkLeaf(n.elems[i:])
+	}
+	ls := n.left.size
+	if i <= ls {
+		l, r := split(n.left, i)
+		return l, join(r, n.right)
+	}
+	l, r := split(n.right, i-ls)
+	return join(n.left, l), r
+}
+
+// splitFirst returns n without its first leaf and the elements of that leaf.
+func splitFirst[E any](n *node[E]) (*node[E], []E) {
+	if n.leaf() {
+		return nil, n.elems
+	}
+	l, elems := splitFirst(n.left)
+	return join(l, n.right), elems
+}
+
+// splitLast returns n without its last leaf and the elements of that leaf.
+func splitLast[E any](n *node[E]) (*node[E], []E) {
+	if n.leaf() {
+		return nil, n.elems
+	}
+	r, elems := splitLast(n.right)
+	return join(n.left, r), elems
+}
+
+// do calls f on each element of n in order, starting at index i.
+// It returns false if f returned false.
+func do[E any](n *node[E], i int, f func(int, E) bool) bool {
+	if n == nil {
+		return true
+	}
+	if n.leaf() {
+		for j, v := range n.elems {
+			if !f(i+j, v) {
+				return false
+			}
+		}
+		return true
+	}
+	return do(n.left, i, f) && do(n.right, i+n.left.size, f)
+}
+
+// flatten returns the elements of n in a slice that is never modified.
+// The slice is retained by n, so subsequent calls are O(1).
+func flatten[E any](n *node[E]) []E {
+	if n == nil {
+		return nil
+	}
+	if p := n.flat.Load(); p != nil {
+		return *p
+	}
+	if n.leaf() {
+		return n.elems
+	}
+	s := make([]E, 0, n.size)
+	do(n, 0, func(_ int, v E) bool {
+		s = append(s, v)
+		return true
+	})
+	n.flat.Store(&s)
+	return s
+}
@@ -0,0 +1,89 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package pvslices
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// checkBalanced verifies the AVL invariants of n and returns its number of leaves.
+func checkBalanced[E any](t *testing.T, n *node[E]) int {
+	t.Helper()
+	if n == nil {
+		return 0
+	}
+	if n.leaf() {
+		if n.right != nil || len(n.elems) == 0 || len(n.elems) > chunkSize || cap(n.elems) != len(n.elems) {
+			t.Fatalf("invalid leaf: len = %d, cap = %d", len(n.elems), cap(n.elems))
+		}
+		if n.size != len(n.elems) || n.height != 1 {
+			t.Fatalf("size, height = %d, %d, want %d, %d", n.size, n.height, len(n.elems), 1)
+		}
+		return 1
+	}
+	if n.right == nil {
+		t.Fatalf("internal node with one child")
+	}
+	leaves := checkBalanced(t, n.left) + checkBalanced(t, n.right)
+	hl, hr := height(n.left), height(n.right)
+	if d := hl - hr; d < -1 || d > 1 {
+		t.Fatalf("unbalanced node: height(left) = %d, height(right) = %d", hl, hr)
+	}
+	if n.size != size(n.left)+size(n.right) {
+		t.Fatalf("size = %d, want %d", n.size, size(n.left)+size(n.right))
+	}
+	return leaves
+}
+
+func Test_balanced(t *testing.T) {
+	rand.Seed(42)
+	var s Slice[int]
+	for i := 0; i < 1_000; i++ {
+		s = Insert(s, rand.Intn(s.Len()+1), i, i)
+		if i%3 == 0 {
+			j := rand.Intn(s.Len())
+			s = Delete(s, j, j+1)
+		}
+		checkBalanced(t, s.root)
+	}
+	// seams are merged, so random edits do not fragment the chunks
+	if leaves := checkBalanced(t, s.root); leaves*chunkSize/4 > s.Len() {
+		t.Errorf("leaves = %d for length %d, want at most %d", leaves, s.Len(), 4*s.Len()/chunkSize)
+	}
+	s = Append(s, make([]int, 10_000)...)
+	checkBalanced(t, s.root)
+	s = Delete(s, 10, s.Len()-10)
+	checkBalanced(t, s.root)
+}
+
+func Test_setIndex_shares(t *testing.T) {
+	s1 := CopyOnWrite(make([]int, 1_000))
+	if got, want := checkBalanced(t, s1.root), 1_000/chunkSize+1; got != want {
+		t.Errorf("leaves = %d, want %d", got, want)
+	}
+	s2 := s1
+	s2.SetIndex(0, 1)
+	if s1.Index(0) != 0 || s2.Index(0) != 1 {
+		t.Fatalf("Index(0) = %v %v, want %v %v", s1.Index(0), s2.Index(0), 0, 1)
+	}
+	// the right subtree is untouched
+	if s1.root.right != s2.root.right {
+		t.Errorf("right subtree was copied")
+	}
+}
+
+func Test_flatten(t *testing.T) {
+	s := CopyOnWrite(make([]int, 1_000))
+	ro := s.RO()
+	if p := s.root.flat.Load(); p == nil || len(*p) != ro.Len() {
+		t.Fatalf("flat = %v, want retained", p)
+	}
+	s2 := s
+	s2.SetIndex(0, 1)
+	if ro.Index(0) != 0 || s2.RO().Index(0) != 1 {
+		t.Errorf("Index(0) = %v %v, want %v %v", ro.Index(0), s2.RO().Index(0), 0, 1)
+	}
+}
@@ -0,0 +1,267 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package roconfig
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/phelmkamp/immut/romaps"
+	"github.com/phelmkamp/immut/roslices"
+)
+
+// Snapshot wraps a deep-frozen configuration tree.
+// Objects are represented as romaps.Map[string, any] and arrays as roslices.Slice[any].
+type Snapshot struct {
+	root romaps.Map[string, any]
+}
+
+// Bool returns the value at path as a bool.
+// The boolean value ok is false if the value is not present or not a bool.
+func (s Snapshot) Bool(path string) (b bool, ok bool) {
+	switch v, _ := s.Get(path); v := v.(type) {
+	case bool:
+		return v, true
+	case string:
+		b, err := strconv.ParseBool(v)
+		return b, err == nil
+	}
+	return false, false
+}
+
+// Duration returns the value at path as a time.Duration.
+// Strings are parsed by time.ParseDuration and numbers are interpreted as nanoseconds.
+// The boolean value ok is false if the value is not present or not a duration.
+func (s Snapshot) Duration(path string) (d time.Duration, ok bool) {
+	if v, _ := s.Get(path); v != nil {
+		if str, isStr := v.(string); isStr {
+			d, err := time.ParseDuration(str)
+			return d, err == nil
+		}
+	}
+	n, ok := s.Int(path)
+	return time.Duration(n), ok
+}
+
+// Float returns the value at path as a float64.
+// The boolean value ok is false if the value is not present or not a number.
+func (s Snapshot) Float(path string) (f float64, ok bool) {
+	var err error
+	switch v, _ := s.Get(path); v := v.(type) {
+	case json.Number:
+		f, err = v.Float64()
+	case string:
+		f, err = strconv.ParseFloat(v, 64)
+	default:
+		return 0, false
+	}
+	return f, err == nil
+}
+
+// Get returns the value at path.
+// The path is a dot-separated list of object keys and array indexes, e.g. "servers.0.host".
+// An empty path returns the root object.
+// The boolean value ok is false if the value is not present.
+func (s Snapshot) Get(path string) (v any, ok bool) {
+	if path == "" {
+		return s.root, true
+	}
+	v = s.root
+	for _, k := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case romaps.Map[string, any]:
+			if v, ok = node.Index(k); !ok {
+				return nil, false
+			}
+		case roslices.Slice[any]:
+			i, err := strconv.Atoi(k)
+			if err != nil || i < 0 || i >= node.Len() {
+				return nil, false
+			}
+			v = node.Index(i)
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// Int returns the value at path as an int.
+// The boolean value ok is false if the value is not present or not an integer.
+func (s Snapshot) Int(path string) (n int, ok bool) {
+	var err error
+	switch v, _ := s.Get(path); v := v.(type) {
+	case json.Number:
+		n, err = strconv.Atoi(v.String())
+	case string:
+		n, err = strconv.Atoi(v)
+	default:
+		return 0, false
+	}
+	return n, err == nil
+}
+
+// Map returns the object at path.
+// The boolean value ok is false if the value is not present or not an object.
+func (s Snapshot) Map(path string) (m romaps.Map[string, any], ok bool) {
+	v, _ := s.Get(path)
+	m, ok = v.(romaps.Map[string, any])
+	return
+}
+
+// Root returns the root object.
+func (s Snapshot) Root() romaps.Map[string, any] {
+	return s.root
+}
+
+// Slice returns the array at path.
+// The boolean value ok is false if the value is not present or not an array.
+func (s Snapshot) Slice(path string) (a roslices.Slice[any], ok bool) {
+	v, _ := s.Get(path)
+	a, ok = v.(roslices.Slice[any])
+	return
+}
+
+// String returns the value at path as a string.
+// Numbers and bools are formatted as they appear in JSON.
+// The boolean value ok is false if the value is not present or not a scalar.
+func (s Snapshot) String(path string) (str string, ok bool) {
+	switch v, _ := s.Get(path); v := v.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	}
+	return "", false
+}
+
+// Config holds the current Snapshot of configuration loaded from a list of sources.
+// It is safe for concurrent use by multiple goroutines.
+type Config struct {
+	sources    []Source
+	validators []func(Snapshot) error
+	snap       atomic.Pointer[Snapshot]
+	mu         sync.Mutex // serializes reloads
+	modTimes   []time.Time
+}
+
+// Load loads the given sources in precedence order, with later sources overriding earlier ones,
+// and returns a Config holding the result.
+// Each validator is called on the loaded Snapshot and any error is returned.
+func Load(sources []Source, validators ...func(Snapshot) error) (*Config, error) {
+	c := &Config{sources: sources, validators: validators}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload loads the sources again and atomically publishes the result.
+// If loading or validation fails, the error is returned and the current Snapshot is kept.
+func (c *Config) Reload() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.reload(c.stat())
+}
+
+// Snapshot returns the current Snapshot.
+func (c *Config) Snapshot() Snapshot {
+	return *c.snap.Load()
+}
+
+// Watch polls the file sources at the given interval and reloads when any of them changes.
+// Errors are passed to onErr, which may be nil.
+// Watch blocks until ctx is done.
+func (c *Config) Watch(ctx context.Context, interval time.Duration, onErr func(error)) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		c.mu.Lock()
+		var err error
+		if modTimes := c.stat(); !equalTimes(modTimes, c.modTimes) {
+			err = c.reload(modTimes)
+		}
+		c.mu.Unlock()
+		if err != nil && onErr != nil {
+			onErr(err)
+		}
+	}
+}
+
+func (c *Config) reload(modTimes []time.Time) error {
+	root := make(map[string]any)
+	for _, src := range c.sources {
+		m, err := src.Load()
+		if err != nil {
+			return err
+		}
+		merge(root, m)
+	}
+	snap := Snapshot{root: freeze(root).(romaps.Map[string, any])}
+	for _, validate := range c.validators {
+		if err := validate(snap); err != nil {
+			return fmt.Errorf("roconfig: invalid config: %w", err)
+		}
+	}
+	c.modTimes = modTimes
+	c.snap.Store(&snap)
+	return nil
+}
+
+// stat returns the modification times of the watchable sources.
+func (c *Config) stat() []time.Time {
+	var modTimes []time.Time
+	for _, src := range c.sources {
+		if mt, ok := src.(modTimer); ok {
+			t, _ := mt.modTime()
+			modTimes = append(modTimes, t)
+		}
+	}
+	return modTimes
+}
+
+func equalTimes(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// freeze recursively copies objects and arrays into their read-only forms.
+func freeze(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, v2 := range v {
+			m[k] = freeze(v2)
+		}
+		return romaps.Freeze(m)
+	case []any:
+		s := make([]any, len(v))
+		for i, v2 := range v {
+			s[i] = freeze(v2)
+		}
+		return roslices.Freeze(s)
+	}
+	return v
+}
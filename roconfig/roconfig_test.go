@@ -0,0 +1,184 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package roconfig_test
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/phelmkamp/immut/roconfig"
+)
+
+func writeFile(t *testing.T, name, data string) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Example() {
+	src := roconfig.SourceFunc(func() (map[string]any, error) {
+		return map[string]any{"db": map[string]any{"host": "localhost", "port": "5432"}}, nil
+	})
+	cfg, err := roconfig.Load([]roconfig.Source{src})
+	if err != nil {
+		panic(err)
+	}
+	snap := cfg.Snapshot()
+	host, _ := snap.String("db.host")
+	port, _ := snap.Int("db.port")
+	fmt.Println(host, port)
+	// Output: localhost 5432
+}
+
+func TestLoad_precedence(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, name, `{"db": {"host": "file", "port": 5432, "ssl": true}, "servers": [{"name": "a"}, {"name": "b"}]}`)
+	t.Setenv("TEST_DB__HOST", "env")
+	t.Setenv("TEST_DB__USER", "env")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("db.user", "default", "")
+	fs.String("db.name", "default", "")
+	if err := fs.Parse([]string{"-db.user=flag"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := roconfig.Load([]roconfig.Source{roconfig.File(name), roconfig.Env("TEST_"), roconfig.Flags(fs)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap := cfg.Snapshot()
+	tests := []struct {
+		path string
+		want string
+		ok   bool
+	}{
+		{"db.host", "env", true},
+		{"db.user", "flag", true},
+		{"db.port", "5432", true},
+		{"db.ssl", "true", true},
+		{"db.name", "", false},
+		{"servers.1.name", "b", true},
+		{"servers.2.name", "", false},
+		{"db", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := snap.String(tt.path); got != tt.want || ok != tt.ok {
+			t.Errorf("String(%q) = %v %v, want %v %v", tt.path, got, ok, tt.want, tt.ok)
+		}
+	}
+	if got, ok := snap.Int("db.port"); got != 5432 || !ok {
+		t.Errorf("Int() = %v %v, want %v %v", got, ok, 5432, true)
+	}
+	if got, ok := snap.Bool("db.ssl"); !got || !ok {
+		t.Errorf("Bool() = %v %v, want %v %v", got, ok, true, true)
+	}
+	if got, ok := snap.Slice("servers"); got.Len() != 2 || !ok {
+		t.Errorf("Slice() = %v %v, want len %v", got, ok, 2)
+	}
+	if got, ok := snap.Map("db"); got.Len() != 4 || !ok {
+		t.Errorf("Map() = %v %v, want len %v", got, ok, 4)
+	}
+}
+
+func TestSnapshot_Duration(t *testing.T) {
+	src := roconfig.SourceFunc(func() (map[string]any, error) {
+		return map[string]any{"a": "1s", "b": "x"}, nil
+	})
+	cfg, err := roconfig.Load([]roconfig.Source{src})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := cfg.Snapshot().Duration("a"); got != time.Second || !ok {
+		t.Errorf("Duration() = %v %v, want %v %v", got, ok, time.Second, true)
+	}
+	if got, ok := cfg.Snapshot().Duration("b"); ok {
+		t.Errorf("Duration() = %v %v, want %v %v", got, ok, 0, false)
+	}
+}
+
+func TestConfig_Reload(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, name, `{"n": 1}`)
+	errNeg := errors.New("n must not be negative")
+	cfg, err := roconfig.Load([]roconfig.Source{roconfig.File(name)}, func(s roconfig.Snapshot) error {
+		if n, _ := s.Int("n"); n < 0 {
+			return errNeg
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := cfg.Snapshot()
+
+	writeFile(t, name, `{"n": -1}`)
+	if err := cfg.Reload(); !errors.Is(err, errNeg) {
+		t.Errorf("Reload() = %v, want %v", err, errNeg)
+	}
+	if n, _ := cfg.Snapshot().Int("n"); n != 1 {
+		t.Errorf("Int() = %v, want %v", n, 1)
+	}
+
+	writeFile(t, name, `{"n": 2}`)
+	if err := cfg.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := cfg.Snapshot().Int("n"); n != 2 {
+		t.Errorf("Int() = %v, want %v", n, 2)
+	}
+	if n, _ := old.Int("n"); n != 1 {
+		t.Errorf("old Int() = %v, want %v", n, 1)
+	}
+}
+
+func TestConfig_Watch(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, name, `{"n": 1}`)
+	cfg, err := roconfig.Load([]roconfig.Source{roconfig.File(name)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		cfg.Watch(ctx, time.Millisecond, func(err error) { t.Error(err) })
+		close(done)
+	}()
+
+	writeFile(t, name, `{"n": 2}`)
+	// ensure the modification time changes on coarse-grained file systems
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(name, future, future); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if n, _ := cfg.Snapshot().Int("n"); n == 2 {
+			break
+		}
+	}
+	if n, _ := cfg.Snapshot().Int("n"); n != 2 {
+		t.Errorf("Int() = %v, want %v", n, 2)
+	}
+	cancel()
+	<-done
+}
+
+func TestLoad_error(t *testing.T) {
+	if _, err := roconfig.Load([]roconfig.Source{roconfig.File(filepath.Join(t.TempDir(), "missing.json"))}); err == nil {
+		t.Errorf("Load() = %v, want error", err)
+	}
+	t.Setenv("TEST_A", "1")
+	t.Setenv("TEST_A__B", "2")
+	if _, err := roconfig.Load([]roconfig.Source{roconfig.Env("TEST_")}); err == nil {
+		t.Errorf("Load() = %v, want error", err)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package roconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Source defines a method for loading a configuration tree.
+// Objects are represented as map[string]any and arrays as []any.
+type Source interface {
+	Load() (map[string]any, error)
+}
+
+// SourceFunc is a func that implements Source.
+type SourceFunc func() (map[string]any, error)
+
+// Load calls f.
+func (f SourceFunc) Load() (map[string]any, error) {
+	return f()
+}
+
+// modTimer is implemented by sources that can be watched for changes.
+type modTimer interface {
+	modTime() (time.Time, error)
+}
+
+type envSource string
+
+// Env returns a Source that loads the environment variables with the given prefix.
+// The prefix is trimmed and the remaining name is lowercased and split on "__" to form a path,
+// so APP_DB__MAX_CONNS=8 loads as {"db": {"max_conns": "8"}} given the prefix "APP_".
+func Env(prefix string) Source {
+	return envSource(prefix)
+}
+
+func (prefix envSource) Load() (map[string]any, error) {
+	m := make(map[string]any)
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(k, string(prefix)) {
+			continue
+		}
+		path := strings.Split(strings.ToLower(strings.TrimPrefix(k, string(prefix))), "__")
+		if err := setPath(m, path, v); err != nil {
+			return nil, fmt.Errorf("roconfig: env %s: %w", k, err)
+		}
+	}
+	return m, nil
+}
+
+type fileSource string
+
+// File returns a Source that loads the JSON object in the named file.
+// The file is watched for changes by Config.Watch.
+func File(name string) Source {
+	return fileSource(name)
+}
+
+func (name fileSource) Load() (map[string]any, error) {
+	b, err := os.ReadFile(string(name))
+	if err != nil {
+		return nil, fmt.Errorf("roconfig: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var m map[string]any
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("roconfig: file %s: %w", name, err)
+	}
+	return m, nil
+}
+
+func (name fileSource) modTime() (time.Time, error) {
+	fi, err := os.Stat(string(name))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return fi.ModTime(), nil
+}
+
+type flagSource struct {
+	fs *flag.FlagSet
+}
+
+// Flags returns a Source that loads the flags that have been set in fs.
+// Flag names are split on "." to form a path, so -db.host=x loads as {"db": {"host": "x"}}.
+// Flags that have not been set are omitted so that they do not override other sources.
+func Flags(fs *flag.FlagSet) Source {
+	return flagSource{fs: fs}
+}
+
+func (s flagSource) Load() (map[string]any, error) {
+	m := make(map[string]any)
+	var err error
+	s.fs.Visit(func(f *flag.Flag) {
+		if err == nil {
+			if err2 := setPath(m, strings.Split(f.Name, "."), f.Value.String()); err2 != nil {
+				err = fmt.Errorf("roconfig: flag %s: %w", f.Name, err2)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// setPath sets the value at path in m, creating intermediate objects as needed.
+func setPath(m map[string]any, path []string, v any) error {
+	for i, k := range path[:len(path)-1] {
+		switch next := m[k].(type) {
+		case nil:
+			m2 := make(map[string]any)
+			m[k] = m2
+			m = m2
+		case map[string]any:
+			m = next
+		default:
+			return fmt.Errorf("%s is not an object", strings.Join(path[:i+1], "."))
+		}
+	}
+	k := path[len(path)-1]
+	if _, ok := m[k].(map[string]any); ok {
+		return fmt.Errorf("%s is an object", strings.Join(path, "."))
+	}
+	m[k] = v
+	return nil
+}
+
+// merge deep-merges src into dst, with values in src taking precedence.
+func merge(dst, src map[string]any) {
+	for k, v := range src {
+		if srcM, ok := v.(map[string]any); ok {
+			if dstM, ok := dst[k].(map[string]any); ok {
+				merge(dstM, srcM)
+				continue
+			}
+			dstM := make(map[string]any, len(srcM))
+			merge(dstM, srcM)
+			dst[k] = dstM
+			continue
+		}
+		dst[k] = v
+	}
+}
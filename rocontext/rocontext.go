@@ -0,0 +1,57 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package rocontext
+
+import (
+	"context"
+
+	"github.com/phelmkamp/immut/corptrs"
+	"github.com/phelmkamp/immut/romaps"
+	"github.com/phelmkamp/immut/roslices"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// Key is a typed context key for a read-only value of type T.
+// Keys are compared by identity, so two keys with the same name do not collide.
+type Key[T any] struct {
+	name string
+}
+
+// String returns the name of the key.
+func (k *Key[T]) String() string {
+	return k.name
+}
+
+// Value returns the value associated with k in ctx.
+// The boolean value ok is false if no such value is present.
+func (k *Key[T]) Value(ctx context.Context) (v T, ok bool) {
+	v, ok = ctx.Value(k).(T)
+	return
+}
+
+// NewKey returns a new Key with the given name.
+// The name is used for debugging only.
+func NewKey[T any](name string) *Key[T] {
+	return &Key[T]{name: name}
+}
+
+// WithMap returns a copy of parent in which the value associated with k is a read-only copy of m.
+// The map is cloned so that subsequent writes to m are not observed.
+func WithMap[K comparable, V any](parent context.Context, k *Key[romaps.Map[K, V]], m map[K]V) context.Context {
+	return context.WithValue(parent, k, romaps.Freeze(maps.Clone(m)))
+}
+
+// WithPointer returns a copy of parent in which the value associated with k is a read-only copy of *p.
+// The pointed-to value is cloned so that subsequent writes through p are not observed.
+func WithPointer[T any](parent context.Context, k *Key[corptrs.Pointer[T]], p *T) context.Context {
+	return context.WithValue(parent, k, corptrs.Freeze(corptrs.Freeze(p).Clone()))
+}
+
+// WithSlice returns a copy of parent in which the value associated with k is a read-only copy of s.
+// The slice is cloned so that subsequent writes to s are not observed.
+func WithSlice[E any](parent context.Context, k *Key[roslices.Slice[E]], s []E) context.Context {
+	return context.WithValue(parent, k, roslices.Freeze(slices.Clone(s)))
+}
@@ -0,0 +1,71 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package rocontext_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/phelmkamp/immut/corptrs"
+	"github.com/phelmkamp/immut/rocontext"
+	"github.com/phelmkamp/immut/romaps"
+	"github.com/phelmkamp/immut/roslices"
+)
+
+var rolesKey = rocontext.NewKey[roslices.Slice[string]]("roles")
+
+func Example() {
+	roles := []string{"admin", "user"}
+	ctx := rocontext.WithSlice(context.Background(), rolesKey, roles)
+	roles[0] = "guest" // not observed
+
+	ro, _ := rolesKey.Value(ctx)
+	fmt.Println(ro)
+	// Output: [admin user]
+}
+
+func TestWithMap(t *testing.T) {
+	k := rocontext.NewKey[romaps.Map[string, int]]("cfg")
+	m := map[string]int{"foo": 42}
+	ctx := rocontext.WithMap(context.Background(), k, m)
+	m["foo"] = 7
+	got, ok := k.Value(ctx)
+	if want := romaps.Freeze(map[string]int{"foo": 42}); !ok || !romaps.Equal(got, want) {
+		t.Errorf("Value() = %v %v, want %v %v", got, ok, want, true)
+	}
+}
+
+func TestWithPointer(t *testing.T) {
+	type user struct{ name string }
+	k := rocontext.NewKey[corptrs.Pointer[user]]("user")
+	u := &user{"foo"}
+	ctx := rocontext.WithPointer(context.Background(), k, u)
+	u.name = "bar"
+	got, ok := k.Value(ctx)
+	if !ok || got.Clone().name != "foo" {
+		t.Errorf("Value() = %v %v, want %v %v", got, ok, &user{"foo"}, true)
+	}
+
+	ctx = rocontext.WithPointer[user](context.Background(), k, nil)
+	if got, ok := k.Value(ctx); !ok || !got.IsNil() {
+		t.Errorf("Value() = %v %v, want %v %v", got, ok, nil, true)
+	}
+}
+
+func TestKey_Value(t *testing.T) {
+	k1 := rocontext.NewKey[roslices.Slice[int]]("ints")
+	k2 := rocontext.NewKey[roslices.Slice[int]]("ints")
+	ctx := rocontext.WithSlice(context.Background(), k1, []int{1})
+	if got, ok := k2.Value(ctx); ok || !got.IsNil() {
+		t.Errorf("Value() = %v %v, want %v %v", got, ok, nil, false)
+	}
+	if got, ok := k1.Value(ctx); !ok || got.Len() != 1 {
+		t.Errorf("Value() = %v %v, want %v %v", got, ok, []int{1}, true)
+	}
+	if got := k1.String(); got != "ints" {
+		t.Errorf("String() = %v, want %v", got, "ints")
+	}
+}
@@ -0,0 +1,6 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package rojson defines read-only JSON document trees.
+package rojson
@@ -0,0 +1,263 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package rojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/phelmkamp/immut/romaps"
+	"github.com/phelmkamp/immut/roslices"
+)
+
+// Kind is the kind of JSON value held by a Node.
+type Kind int
+
+const (
+	Invalid Kind = iota // not present
+	Null
+	Bool
+	Number
+	String
+	Array
+	Object
+)
+
+var kindNames = [...]string{"invalid", "null", "bool", "number", "string", "array", "object"}
+
+// String returns the name of the kind.
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return "Kind(" + strconv.Itoa(int(k)) + ")"
+	}
+	return kindNames[k]
+}
+
+// Node wraps a read-only JSON value.
+// The zero Node is Invalid, which is returned by lookups of values that are not present
+// so that accessors can be chained, e.g. n.Get("a").Index(3).String().
+type Node struct {
+	kind Kind
+	v    any // bool, json.Number, string, roslices.Slice[Node] or romaps.Map[string, Node]
+}
+
+// Array returns the elements of an Array node.
+// It returns a nil Slice for any other kind.
+func (n Node) Array() roslices.Slice[Node] {
+	a, _ := n.v.(roslices.Slice[Node])
+	return a
+}
+
+// Bool returns the value of a Bool node.
+// It returns false for any other kind.
+func (n Node) Bool() bool {
+	b, _ := n.v.(bool)
+	return b
+}
+
+// Exists reports whether the node is present, i.e. not Invalid.
+func (n Node) Exists() bool {
+	return n.kind != Invalid
+}
+
+// Float returns the value of a Number node as a float64.
+// It returns 0 for any other kind or if the number is out of range.
+func (n Node) Float() float64 {
+	f, _ := n.Number().Float64()
+	return f
+}
+
+// Get returns the value associated with k in an Object node.
+// It returns an Invalid node if k is not present or n is not an Object.
+func (n Node) Get(k string) Node {
+	v, _ := n.Object().Index(k)
+	return v
+}
+
+// Index returns the i'th element of an Array node.
+// It returns an Invalid node if i is out of range or n is not an Array.
+func (n Node) Index(i int) Node {
+	a := n.Array()
+	if i < 0 || i >= a.Len() {
+		return Node{}
+	}
+	return a.Index(i)
+}
+
+// Int returns the value of a Number node as an int64.
+// It returns 0 for any other kind or if the number is not an integer.
+func (n Node) Int() int64 {
+	i, _ := n.Number().Int64()
+	return i
+}
+
+// IsNull reports whether n is Null.
+func (n Node) IsNull() bool {
+	return n.kind == Null
+}
+
+// Kind returns the kind of n.
+func (n Node) Kind() Kind {
+	return n.kind
+}
+
+// Len returns the length of an Array or Object node.
+// It returns 0 for any other kind.
+func (n Node) Len() int {
+	switch v := n.v.(type) {
+	case roslices.Slice[Node]:
+		return v.Len()
+	case romaps.Map[string, Node]:
+		return v.Len()
+	}
+	return 0
+}
+
+// MarshalJSON returns the JSON encoding of n.
+func (n Node) MarshalJSON() ([]byte, error) {
+	switch n.kind {
+	case Invalid:
+		return nil, errors.New("rojson: cannot marshal invalid node")
+	case Null:
+		return []byte("null"), nil
+	case Array:
+		var buf bytes.Buffer
+		buf.WriteByte('[')
+		a := n.Array()
+		for i := 0; i < a.Len(); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			b, err := a.Index(i).MarshalJSON()
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(b)
+		}
+		buf.WriteByte(']')
+		return buf.Bytes(), nil
+	case Object:
+		// Convert to a mutable map so that keys are sorted by encoding/json.
+		return json.Marshal(romaps.Clone(n.Object()))
+	}
+	return json.Marshal(n.v)
+}
+
+// Number returns the value of a Number node.
+// It returns "" for any other kind.
+func (n Node) Number() json.Number {
+	num, _ := n.v.(json.Number)
+	return num
+}
+
+// Object returns the members of an Object node.
+// It returns a nil Map for any other kind.
+func (n Node) Object() romaps.Map[string, Node] {
+	m, _ := n.v.(romaps.Map[string, Node])
+	return m
+}
+
+// Pointer returns the node referenced by the given JSON Pointer (RFC 6901).
+// The empty pointer references n itself.
+func (n Node) Pointer(ptr string) (Node, error) {
+	if ptr == "" {
+		return n, nil
+	}
+	if ptr[0] != '/' {
+		return Node{}, fmt.Errorf("rojson: invalid pointer %q: must begin with /", ptr)
+	}
+	for _, tok := range strings.Split(ptr[1:], "/") {
+		tok = strings.ReplaceAll(strings.ReplaceAll(tok, "~1", "/"), "~0", "~")
+		switch n.kind {
+		case Object:
+			v, ok := n.Object().Index(tok)
+			if !ok {
+				return Node{}, fmt.Errorf("rojson: pointer %q: key %q not found", ptr, tok)
+			}
+			n = v
+		case Array:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || (len(tok) > 1 && tok[0] == '0') || tok[0] == '+' {
+				return Node{}, fmt.Errorf("rojson: pointer %q: invalid array index %q", ptr, tok)
+			}
+			if i >= n.Len() {
+				return Node{}, fmt.Errorf("rojson: pointer %q: index %d out of range", ptr, i)
+			}
+			n = n.Index(i)
+		default:
+			return Node{}, fmt.Errorf("rojson: pointer %q: cannot index %v with %q", ptr, n.kind, tok)
+		}
+	}
+	return n, nil
+}
+
+// String returns the value of a String node.
+// For any other kind, it returns the JSON encoding of n, or "" if n is Invalid.
+func (n Node) String() string {
+	switch n.kind {
+	case Invalid:
+		return ""
+	case String:
+		return n.v.(string)
+	}
+	b, _ := n.MarshalJSON()
+	return string(b)
+}
+
+// Decode reads the next JSON value from r and returns it as a read-only tree.
+func Decode(r io.Reader) (Node, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return Node{}, fmt.Errorf("rojson: %w", err)
+	}
+	return freeze(v), nil
+}
+
+// Parse parses the JSON-encoded data and returns it as a read-only tree.
+func Parse(data []byte) (Node, error) {
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return Node{}, fmt.Errorf("rojson: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return Node{}, errors.New("rojson: invalid character after top-level value")
+	}
+	return freeze(v), nil
+}
+
+func freeze(v any) Node {
+	switch v := v.(type) {
+	case nil:
+		return Node{kind: Null}
+	case bool:
+		return Node{kind: Bool, v: v}
+	case json.Number:
+		return Node{kind: Number, v: v}
+	case string:
+		return Node{kind: String, v: v}
+	case []any:
+		a := make([]Node, len(v))
+		for i, v2 := range v {
+			a[i] = freeze(v2)
+		}
+		return Node{kind: Array, v: roslices.Freeze(a)}
+	case map[string]any:
+		m := make(map[string]Node, len(v))
+		for k, v2 := range v {
+			m[k] = freeze(v2)
+		}
+		return Node{kind: Object, v: romaps.Freeze(m)}
+	}
+	panic(fmt.Sprintf("rojson: unexpected type %T", v))
+}
@@ -0,0 +1,119 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package rojson_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/phelmkamp/immut/rojson"
+)
+
+func Example() {
+	doc, err := rojson.Parse([]byte(`{"a": [1, 2, 3, "four"], "b": {"c": true}}`))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(doc.Get("a").Index(3).String())
+	fmt.Println(doc.Get("a").Index(1).Int())
+	fmt.Println(doc.Get("b").Get("c").Bool())
+	fmt.Println(doc.Get("x").Index(0).Exists())
+	// Output: four
+	// 2
+	// true
+	// false
+}
+
+// rfc6901 is the example document from RFC 6901, section 5.
+const rfc6901 = `{
+	"foo": ["bar", "baz"],
+	"": 0,
+	"a/b": 1,
+	"c%d": 2,
+	"e^f": 3,
+	"g|h": 4,
+	"i\\j": 5,
+	"k\"l": 6,
+	" ": 7,
+	"m~n": 8
+}`
+
+func TestNode_Pointer(t *testing.T) {
+	doc, err := rojson.Parse([]byte(rfc6901))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		ptr     string
+		want    string
+		wantErr bool
+	}{
+		{"", `{"":0," ":7,"a/b":1,"c%d":2,"e^f":3,"foo":["bar","baz"],"g|h":4,"i\\j":5,"k\"l":6,"m~n":8}`, false},
+		{"/foo", `["bar","baz"]`, false},
+		{"/foo/0", "bar", false},
+		{"/", "0", false},
+		{"/a~1b", "1", false},
+		{"/c%d", "2", false},
+		{"/e^f", "3", false},
+		{"/g|h", "4", false},
+		{"/i\\j", "5", false},
+		{"/k\"l", "6", false},
+		{"/ ", "7", false},
+		{"/m~0n", "8", false},
+		{"foo", "", true},
+		{"/bar", "", true},
+		{"/foo/2", "", true},
+		{"/foo/-", "", true},
+		{"/foo/01", "", true},
+		{"/foo/0/x", "", true},
+	}
+	for _, tt := range tests {
+		got, err := doc.Pointer(tt.ptr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Pointer(%q) error = %v, wantErr %v", tt.ptr, err, tt.wantErr)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("Pointer(%q) = %v, want %v", tt.ptr, got, tt.want)
+		}
+	}
+}
+
+func TestNode_Kind(t *testing.T) {
+	doc, err := rojson.Decode(strings.NewReader(`[null, true, 1.5, "s", [], {}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []rojson.Kind{rojson.Null, rojson.Bool, rojson.Number, rojson.String, rojson.Array, rojson.Object, rojson.Invalid}
+	for i, k := range want {
+		if got := doc.Index(i).Kind(); got != k {
+			t.Errorf("Index(%d).Kind() = %v, want %v", i, got, k)
+		}
+	}
+	if got := doc.Len(); got != 6 {
+		t.Errorf("Len() = %v, want %v", got, 6)
+	}
+	if !doc.Index(0).IsNull() {
+		t.Errorf("IsNull() = %v, want %v", false, true)
+	}
+	if got := doc.Index(2).Float(); got != 1.5 {
+		t.Errorf("Float() = %v, want %v", got, 1.5)
+	}
+	if got := doc.Index(2).Int(); got != 0 {
+		t.Errorf("Int() = %v, want %v", got, 0)
+	}
+	if got := doc.String(); got != `[null,true,1.5,"s",[],{}]` {
+		t.Errorf("String() = %v, want %v", got, `[null,true,1.5,"s",[],{}]`)
+	}
+}
+
+func TestParse_error(t *testing.T) {
+	for _, s := range []string{"", "{", "1 2", "[]]"} {
+		if _, err := rojson.Parse([]byte(s)); err == nil {
+			t.Errorf("Parse(%q) error = nil, want error", s)
+		}
+	}
+}
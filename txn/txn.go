@@ -0,0 +1,205 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package txn
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrConflict is returned by Commit if a cell read or written by the transaction
+// was changed by another transaction since it began.
+var ErrConflict = errors.New("txn: conflict")
+
+// ErrDone is returned by Commit if the transaction has already been committed or rolled back.
+var ErrDone = errors.New("txn: transaction already done")
+
+// state is an immutable version of all cells in a Store.
+type state struct {
+	version uint64
+	vals    []any
+	vers    []uint64 // version of the last commit that wrote each cell
+}
+
+// Store holds a set of cells whose values change together.
+// Values stored in cells must not be modified after they are set.
+// A Store is safe for concurrent use by multiple goroutines.
+// The zero value is an empty Store.
+type Store struct {
+	mu sync.Mutex // serializes commits
+	st atomic.Pointer[state]
+}
+
+// Begin starts a transaction against the current version of s.
+func (s *Store) Begin() *Txn {
+	return &Txn{s: s, snap: s.load(), reads: make(map[int]uint64), writes: make(map[int]any)}
+}
+
+// Snapshot returns a consistent view of every cell at the current version of s.
+func (s *Store) Snapshot() Snapshot {
+	return Snapshot{s: s, st: s.load()}
+}
+
+// Update runs f in a new transaction and commits it, retrying while the commit fails with ErrConflict.
+// If f returns an error, the transaction is rolled back and the error is returned.
+func (s *Store) Update(f func(tx *Txn) error) error {
+	for {
+		tx := s.Begin()
+		if err := f(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); !errors.Is(err, ErrConflict) {
+			return err
+		}
+	}
+}
+
+func (s *Store) load() *state {
+	if st := s.st.Load(); st != nil {
+		return st
+	}
+	return &state{}
+}
+
+// Snapshot is a consistent view of the cells of a Store at a single version.
+type Snapshot struct {
+	s  *Store
+	st *state
+}
+
+// Version returns the version of the Store that s reflects.
+// It increases with every commit.
+func (s Snapshot) Version() uint64 {
+	return s.st.version
+}
+
+// Txn is a transaction that stages changes to cells and publishes them atomically on Commit.
+// A Txn is not safe for concurrent use by multiple goroutines.
+type Txn struct {
+	s      *Store
+	snap   *state
+	reads  map[int]uint64 // version of each cell observed by the transaction
+	writes map[int]any
+	done   bool
+}
+
+// Commit publishes all staged changes atomically, or none of them.
+// It returns ErrConflict if any cell read or written by tx has been changed since tx began.
+func (tx *Txn) Commit() error {
+	if tx.done {
+		return ErrDone
+	}
+	tx.done = true
+	s := tx.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur := s.load()
+	for id, ver := range tx.reads {
+		if cur.vers[id] != ver {
+			return ErrConflict
+		}
+	}
+	// Avoid new version if nothing was written.
+	if len(tx.writes) == 0 {
+		return nil
+	}
+	st := &state{
+		version: cur.version + 1,
+		vals:    append([]any(nil), cur.vals...),
+		vers:    append([]uint64(nil), cur.vers...),
+	}
+	for id, v := range tx.writes {
+		st.vals[id] = v
+		st.vers[id] = st.version
+	}
+	s.st.Store(st)
+	return nil
+}
+
+// Rollback discards all staged changes.
+func (tx *Txn) Rollback() {
+	tx.done = true
+}
+
+// observe returns the value of cell id as of the start of the transaction and records its version.
+func (tx *Txn) observe(id int) any {
+	st := tx.snap
+	if id >= len(st.vals) {
+		// The cell was created after the transaction began.
+		st = tx.s.load()
+	}
+	if _, ok := tx.reads[id]; !ok {
+		tx.reads[id] = st.vers[id]
+	}
+	return st.vals[id]
+}
+
+// Cell holds a value of type T in a Store.
+type Cell[T any] struct {
+	s  *Store
+	id int
+}
+
+// At returns the value of c in snap.
+// It returns the zero value if c was created after snap was taken.
+func (c *Cell[T]) At(snap Snapshot) (v T) {
+	c.check(snap.s)
+	if c.id >= len(snap.st.vals) {
+		return
+	}
+	return as[T](snap.st.vals[c.id])
+}
+
+// Get returns the value of c within tx: the value staged by Set,
+// or else the value as of the start of tx.
+func (c *Cell[T]) Get(tx *Txn) T {
+	c.check(tx.s)
+	if v, ok := tx.writes[c.id]; ok {
+		return as[T](v)
+	}
+	return as[T](tx.observe(c.id))
+}
+
+// Load returns the current value of c.
+func (c *Cell[T]) Load() T {
+	return as[T](c.s.load().vals[c.id])
+}
+
+// Set stages v as the value of c, to be published when tx commits.
+// v must not be modified afterwards.
+func (c *Cell[T]) Set(tx *Txn, v T) {
+	c.check(tx.s)
+	tx.observe(c.id)
+	tx.writes[c.id] = v
+}
+
+func (c *Cell[T]) check(s *Store) {
+	if c.s != s {
+		panic("txn: cell belongs to a different Store")
+	}
+}
+
+// as converts v to T, allowing v to be a nil interface value.
+func as[T any](v any) T {
+	t, _ := v.(T)
+	return t
+}
+
+// NewCell adds a cell with the initial value v to s.
+// v must not be modified afterwards.
+func NewCell[T any](s *Store, v T) *Cell[T] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur := s.load()
+	st := &state{
+		version: cur.version,
+		vals:    append(append(make([]any, 0, len(cur.vals)+1), cur.vals...), v),
+		vers:    append(append(make([]uint64, 0, len(cur.vers)+1), cur.vers...), cur.version),
+	}
+	s.st.Store(st)
+	return &Cell[T]{s: s, id: len(cur.vals)}
+}
@@ -0,0 +1,124 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package txn_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/phelmkamp/immut/cowmaps"
+	"github.com/phelmkamp/immut/cowslices"
+	"github.com/phelmkamp/immut/txn"
+)
+
+func Example() {
+	var s txn.Store
+	index := txn.NewCell(&s, cowmaps.CopyOnWrite(map[string]int{}))
+	reverse := txn.NewCell(&s, cowmaps.CopyOnWrite(map[int]string{}))
+
+	_ = s.Update(func(tx *txn.Txn) error {
+		m1, m2 := index.Get(tx), reverse.Get(tx)
+		m1.SetIndex("foo", 1)
+		m2.SetIndex(1, "foo")
+		index.Set(tx, m1)
+		reverse.Set(tx, m2)
+		return nil
+	})
+
+	snap := s.Snapshot()
+	fmt.Println(index.At(snap), reverse.At(snap))
+	// Output: map[foo:1] map[1:foo]
+}
+
+func TestTxn_conflict(t *testing.T) {
+	var s txn.Store
+	c := txn.NewCell(&s, cowslices.CopyOnWrite([]int{1}))
+	tx1, tx2 := s.Begin(), s.Begin()
+	c.Set(tx1, cowslices.Append(c.Get(tx1), 2))
+	c.Set(tx2, cowslices.Append(c.Get(tx2), 3))
+	if err := tx1.Commit(); err != nil {
+		t.Fatalf("Commit() = %v, want %v", err, nil)
+	}
+	if err := tx2.Commit(); !errors.Is(err, txn.ErrConflict) {
+		t.Errorf("Commit() = %v, want %v", err, txn.ErrConflict)
+	}
+	if err := tx1.Commit(); !errors.Is(err, txn.ErrDone) {
+		t.Errorf("Commit() = %v, want %v", err, txn.ErrDone)
+	}
+	if got, want := c.Load().String(), "[1 2]"; got != want {
+		t.Errorf("Load() = %v, want %v", got, want)
+	}
+
+	// reads are validated too
+	other := txn.NewCell(&s, 0)
+	tx3 := s.Begin()
+	_ = c.Get(tx3)
+	other.Set(tx3, 1)
+	_ = s.Update(func(tx *txn.Txn) error {
+		c.Set(tx, cowslices.CopyOnWrite([]int{0}))
+		return nil
+	})
+	if err := tx3.Commit(); !errors.Is(err, txn.ErrConflict) {
+		t.Errorf("Commit() = %v, want %v", err, txn.ErrConflict)
+	}
+	if got := other.Load(); got != 0 {
+		t.Errorf("Load() = %v, want %v", got, 0)
+	}
+}
+
+func TestStore_Update(t *testing.T) {
+	var s txn.Store
+	a := txn.NewCell(&s, 100)
+	b := txn.NewCell(&s, 0)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				err := s.Update(func(tx *txn.Txn) error {
+					a.Set(tx, a.Get(tx)-1)
+					b.Set(tx, b.Get(tx)+1)
+					return nil
+				})
+				if err != nil {
+					t.Errorf("Update() = %v, want %v", err, nil)
+				}
+				// readers never observe a partial transfer
+				if snap := s.Snapshot(); a.At(snap)+b.At(snap) != 100 {
+					t.Errorf("At() = %v %v, want sum %v", a.At(snap), b.At(snap), 100)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if got, want := a.Load(), 0; got != want {
+		t.Errorf("Load() = %v, want %v", got, want)
+	}
+	if got, want := s.Snapshot().Version(), uint64(100); got != want {
+		t.Errorf("Version() = %v, want %v", got, want)
+	}
+
+	errStop := errors.New("stop")
+	if err := s.Update(func(tx *txn.Txn) error {
+		a.Set(tx, 42)
+		return errStop
+	}); err != errStop {
+		t.Errorf("Update() = %v, want %v", err, errStop)
+	}
+	if got := a.Load(); got != 0 {
+		t.Errorf("Load() = %v, want %v", got, 0)
+	}
+}
+
+func TestCell_nil(t *testing.T) {
+	var s txn.Store
+	c := txn.NewCell[error](&s, nil)
+	if got := c.Load(); got != nil {
+		t.Errorf("Load() = %v, want %v", got, nil)
+	}
+}
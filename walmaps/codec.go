@@ -0,0 +1,42 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package walmaps
+
+import (
+	"encoding/json"
+)
+
+// Codec converts values of type T to and from bytes.
+type Codec[T any] interface {
+	Marshal(v T) ([]byte, error)
+	Unmarshal(data []byte) (T, error)
+}
+
+// JSONCodec is a Codec that uses encoding/json.
+type JSONCodec[T any] struct{}
+
+// Marshal returns the JSON encoding of v.
+func (JSONCodec[T]) Marshal(v T) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal parses the JSON-encoded data.
+func (JSONCodec[T]) Unmarshal(data []byte) (v T, err error) {
+	err = json.Unmarshal(data, &v)
+	return
+}
+
+// StringCodec is a Codec that stores strings as their bytes.
+type StringCodec struct{}
+
+// Marshal returns the bytes of v.
+func (StringCodec) Marshal(v string) ([]byte, error) {
+	return []byte(v), nil
+}
+
+// Unmarshal returns data as a string.
+func (StringCodec) Unmarshal(data []byte) (string, error) {
+	return string(data), nil
+}
@@ -0,0 +1,6 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+// Package walmaps defines a durable copy-on-write map backed by a snapshot and write-ahead log.
+package walmaps
@@ -0,0 +1,89 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package walmaps
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+// A record is stored as:
+//
+//	crc32 (4 bytes) | payload length (4 bytes) | op (1 byte) | key length (uvarint) | key | value
+//
+// where the checksum covers the payload, which starts at op.
+// Integers are little-endian.
+
+const (
+	opSet    byte = 1
+	opDelete byte = 2
+
+	headerLen = 8
+)
+
+// appendRecord appends the encoding of a record to b.
+func appendRecord(b []byte, op byte, k, v []byte) []byte {
+	start := len(b)
+	b = append(b, make([]byte, headerLen)...)
+	b = append(b, op)
+	b = binary.AppendUvarint(b, uint64(len(k)))
+	b = append(b, k...)
+	b = append(b, v...)
+	payload := b[start+headerLen:]
+	binary.LittleEndian.PutUint32(b[start:], crc32.ChecksumIEEE(payload))
+	binary.LittleEndian.PutUint32(b[start+4:], uint32(len(payload)))
+	return b
+}
+
+// readRecords calls f for each record in r, which holds size bytes,
+// and returns the number of bytes of valid records.
+// Reading stops without error at the end of r or at a torn or corrupt record,
+// which is expected at the end of a log after a crash.
+func readRecords(r io.Reader, size int64, f func(op byte, k, v []byte) error) (int64, error) {
+	br := bufio.NewReader(r)
+	var n int64
+	header := make([]byte, headerLen)
+	for {
+		if _, err := io.ReadFull(br, header); err != nil {
+			return n, nil
+		}
+		sum := binary.LittleEndian.Uint32(header)
+		plen := int64(binary.LittleEndian.Uint32(header[4:]))
+		if plen > size-n-headerLen {
+			return n, nil // torn, so don't trust the length
+		}
+		payload := make([]byte, plen)
+		if _, err := io.ReadFull(br, payload); err != nil {
+			return n, nil
+		}
+		if crc32.ChecksumIEEE(payload) != sum {
+			return n, nil
+		}
+		op, k, v, err := parseRecord(payload)
+		if err != nil {
+			return n, nil
+		}
+		if err := f(op, k, v); err != nil {
+			return n, err
+		}
+		n += int64(headerLen + len(payload))
+	}
+}
+
+func parseRecord(payload []byte) (op byte, k, v []byte, err error) {
+	if len(payload) < 1 {
+		return 0, nil, nil, ErrCorrupt
+	}
+	op = payload[0]
+	klen, m := binary.Uvarint(payload[1:])
+	if m <= 0 || klen > uint64(len(payload)-1-m) {
+		return 0, nil, nil, ErrCorrupt
+	}
+	k = payload[1+m : 1+m+int(klen)]
+	v = payload[1+m+int(klen):]
+	return op, k, v, nil
+}
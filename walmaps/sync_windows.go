@@ -0,0 +1,10 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package walmaps
+
+// syncDir is a no-op on Windows, where directories cannot be synced.
+func syncDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,466 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package walmaps
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/phelmkamp/immut/cowmaps"
+	"github.com/phelmkamp/immut/romaps"
+)
+
+const (
+	snapshotName = "snapshot"
+	logName      = "wal"
+)
+
+// DefaultCompactEvery is the number of log records after which a Map created
+// with a CompactEvery of zero writes a snapshot and truncates its log.
+const DefaultCompactEvery = 1000
+
+// DefaultSyncInterval is the interval used by SyncInterval when Options.Interval is zero or less.
+const DefaultSyncInterval = time.Second
+
+// ErrClosed is returned by writes to a Map that has been closed.
+var ErrClosed = errors.New("walmaps: map is closed")
+
+// ErrCorrupt is returned by Open if the snapshot contains a corrupt record.
+var ErrCorrupt = errors.New("walmaps: corrupt record")
+
+// SyncPolicy determines when the log is flushed to stable storage.
+type SyncPolicy int
+
+const (
+	// SyncAlways syncs the log after every write, so a write that returned survives a crash.
+	SyncAlways SyncPolicy = iota
+	// SyncInterval syncs the log periodically, so a crash may lose writes made within the interval.
+	SyncInterval
+	// SyncNever leaves syncing to the operating system.
+	SyncNever
+)
+
+// Options configures a Map.
+// The zero value uses SyncAlways and DefaultCompactEvery.
+type Options struct {
+	Sync SyncPolicy
+	// Interval is the period of SyncInterval; zero or less means DefaultSyncInterval.
+	Interval time.Duration
+	// CompactEvery is the number of log records after which a snapshot is written and the log is truncated.
+	// Zero means DefaultCompactEvery; a negative value disables automatic compaction.
+	CompactEvery int
+}
+
+// Map is a copy-on-write map that appends every write to a write-ahead log before applying it.
+// The log is periodically compacted into a snapshot of the full map.
+// Writes are applied in place to a private copy, which the next read freezes into an immutable snapshot,
+// so a run of writes between reads copies the map only once.
+// Reads return immutable snapshots and do not lock unless the map was written since the last read.
+// It is safe for concurrent use by multiple goroutines.
+type Map[K comparable, V any] struct {
+	mu      sync.Mutex // serializes writers
+	dir     string
+	m       cowmaps.Map[K, V]                // last snapshot
+	t       *cowmaps.TransientMap[K, V]      // writes since the last snapshot, if any
+	ro      atomic.Pointer[romaps.Map[K, V]] // m.RO, or nil if t holds newer writes
+	log     *os.File
+	size    int64 // offset of the end of the last valid record in the log
+	records int   // number of records in the log
+	err     error // error from automatic compaction or a background sync, reported once (see Sync)
+	kc      Codec[K]
+	vc      Codec[V]
+	opts    Options
+	buf     []byte
+	stop    chan struct{} // closed by Close to stop the sync goroutine
+	wg      sync.WaitGroup
+	closed  bool
+}
+
+// Close syncs and closes the log. The map must not be written afterwards.
+// It also reports a pending error from automatic compaction or a background sync (see Sync).
+func (m *Map[K, V]) Close() error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return ErrClosed
+	}
+	m.closed = true
+	if m.stop != nil {
+		close(m.stop)
+	}
+	m.mu.Unlock()
+	m.wg.Wait()
+
+	err := m.log.Sync()
+	if err2 := m.log.Close(); err == nil {
+		err = err2
+	}
+	if err == nil {
+		err = m.err
+	}
+	return err
+}
+
+// Compact writes a snapshot of the map and truncates the log.
+// The snapshot is written to a temporary file and renamed into place,
+// so a crash during compaction leaves either the old or the new snapshot.
+func (m *Map[K, V]) Compact() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
+	}
+	if err := m.compact(); err != nil {
+		return err
+	}
+	m.err = nil
+	return nil
+}
+
+// Delete deletes the element with the specified key from the map.
+// If there is no such element, delete is a no-op and nothing is logged.
+// The delete is logged before it is applied, so if an error is returned the map is unchanged.
+// A pending error is returned instead of deleting (see Sync).
+func (m *Map[K, V]) Delete(k K) (v V, ok bool, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return v, false, ErrClosed
+	}
+	if err = m.takeErr(); err != nil {
+		return v, false, err
+	}
+	// Avoid log record if key not present.
+	if v, ok = m.index(k); !ok {
+		return
+	}
+	kb, err := m.kc.Marshal(k)
+	if err != nil {
+		return v, false, err
+	}
+	if err = m.append(opDelete, kb, nil); err != nil {
+		return v, false, err
+	}
+	m.writable().Delete(k)
+	m.maybeCompact()
+	return v, true, nil
+}
+
+// Index returns the element associated with k in the current snapshot.
+// The boolean value ok is true if the map contains an element with the specified key.
+func (m *Map[K, V]) Index(k K) (v V, ok bool) {
+	return m.Load().Index(k)
+}
+
+// Len returns the number of elements in the current snapshot.
+func (m *Map[K, V]) Len() int {
+	return m.Load().Len()
+}
+
+// Load returns the current snapshot of the map.
+// It is guaranteed not to change.
+func (m *Map[K, V]) Load() romaps.Map[K, V] {
+	if p := m.ro.Load(); p != nil {
+		return *p
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.current()
+}
+
+// SetIndex sets the element associated with k to v.
+// The write is logged before it is applied, so if an error is returned the map is unchanged.
+// A pending error is returned instead of writing (see Sync).
+func (m *Map[K, V]) SetIndex(k K, v V) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
+	}
+	if err := m.takeErr(); err != nil {
+		return err
+	}
+	kb, err := m.kc.Marshal(k)
+	if err != nil {
+		return err
+	}
+	vb, err := m.vc.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err = m.append(opSet, kb, vb); err != nil {
+		return err
+	}
+	m.writable().SetIndex(k, v)
+	m.maybeCompact()
+	return nil
+}
+
+// String returns the current snapshot formatted as a string.
+func (m *Map[K, V]) String() string {
+	return m.Load().String()
+}
+
+// Sync flushes the log to stable storage.
+// A failed automatic compaction does not fail the write that triggered it,
+// and a sync made periodically under SyncInterval has no caller to report to.
+// Instead, such an error is pending until it is returned once by the next call to Sync, SetIndex, Delete or Close.
+// Since a failed sync may have lost writes that were already acknowledged, it must not be ignored.
+// Compaction is retried by the next write that succeeds.
+func (m *Map[K, V]) Sync() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
+	}
+	if err := m.log.Sync(); err != nil {
+		return err
+	}
+	return m.takeErr()
+}
+
+// takeErr returns the pending error, if any, and clears it.
+func (m *Map[K, V]) takeErr() error {
+	err := m.err
+	m.err = nil
+	return err
+}
+
+// append writes a single record to the end of the log.
+// If it fails, the log is truncated to the last valid record
+// so that a torn record is not followed by later records.
+func (m *Map[K, V]) append(op byte, k, v []byte) error {
+	m.buf = appendRecord(m.buf[:0], op, k, v)
+	_, err := m.log.WriteAt(m.buf, m.size)
+	if err == nil && m.opts.Sync == SyncAlways {
+		err = m.log.Sync()
+	}
+	if err != nil {
+		m.log.Truncate(m.size) // best effort; the next record overwrites the torn one anyway
+		return err
+	}
+	m.size += int64(len(m.buf))
+	m.records++
+	return nil
+}
+
+// maybeCompact compacts the log if it has reached the configured number of records.
+// An error is retained until it is reported (see Sync).
+func (m *Map[K, V]) maybeCompact() {
+	n := m.opts.CompactEvery
+	if n == 0 {
+		n = DefaultCompactEvery
+	}
+	if n < 0 || m.records < n {
+		return
+	}
+	if err := m.compact(); err != nil && m.err == nil {
+		m.err = err
+	}
+}
+
+func (m *Map[K, V]) compact() error {
+	tmp, err := os.CreateTemp(m.dir, snapshotName+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	var buf []byte
+	m.current().Do(func(k K, v V) bool {
+		var kb, vb []byte
+		if kb, err = m.kc.Marshal(k); err != nil {
+			return false
+		}
+		if vb, err = m.vc.Marshal(v); err != nil {
+			return false
+		}
+		buf = appendRecord(buf, opSet, kb, vb)
+		return true
+	})
+	if err == nil {
+		_, err = tmp.Write(buf)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if err2 := tmp.Close(); err == nil {
+		err = err2
+	}
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), filepath.Join(m.dir, snapshotName)); err != nil {
+		return err
+	}
+	if err = syncDir(m.dir); err != nil {
+		return err
+	}
+	// Replaying the old log over the new snapshot is harmless,
+	// so a crash before truncation loses nothing.
+	if err = m.log.Truncate(0); err != nil {
+		return err
+	}
+	m.size, m.records = 0, 0
+	return m.log.Sync()
+}
+
+// current freezes any writes since the last snapshot into a new one and returns it.
+func (m *Map[K, V]) current() romaps.Map[K, V] {
+	if m.t != nil {
+		m.m = m.t.Persistent()
+		m.t = nil
+		m.publish()
+	}
+	return m.m.RO
+}
+
+// index returns the element associated with k, including writes since the last snapshot.
+func (m *Map[K, V]) index(k K) (v V, ok bool) {
+	if m.t != nil {
+		return m.t.Index(k)
+	}
+	return m.m.RO.Index(k)
+}
+
+// publish makes the last snapshot visible to Load.
+func (m *Map[K, V]) publish() {
+	ro := m.m.RO
+	m.ro.Store(&ro)
+}
+
+// writable returns the transient map that writes are applied to,
+// copying the last snapshot if there is none.
+func (m *Map[K, V]) writable() *cowmaps.TransientMap[K, V] {
+	if m.t == nil {
+		m.t = cowmaps.Transient(m.m)
+		m.ro.Store(nil)
+	}
+	return m.t
+}
+
+// syncLoop periodically syncs the log until Close is called.
+func (m *Map[K, V]) syncLoop(d time.Duration) {
+	defer m.wg.Done()
+	t := time.NewTicker(d)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			m.mu.Lock()
+			if !m.closed {
+				// A writeback error is reported only once, so retain it for the caller.
+				if err := m.log.Sync(); err != nil && m.err == nil {
+					m.err = err
+				}
+			}
+			m.mu.Unlock()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// Open opens the map stored in dir, creating dir if it does not exist.
+// The map is recovered from the snapshot followed by the records of the log.
+// A torn or corrupt record at the end of the log, as left by a crash, is discarded along with anything after it.
+// Since the snapshot is replaced atomically, a corrupt record in the snapshot results in ErrCorrupt.
+// A nil opts is equivalent to the zero Options.
+func Open[K comparable, V any](dir string, kc Codec[K], vc Codec[V], opts *Options) (*Map[K, V], error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	m := &Map[K, V]{dir: dir, kc: kc, vc: vc}
+	if opts != nil {
+		m.opts = *opts
+	}
+	m2 := make(map[K]V)
+	apply := func(op byte, kb, vb []byte) error {
+		k, err := kc.Unmarshal(kb)
+		if err != nil {
+			return err
+		}
+		switch op {
+		case opSet:
+			v, err := vc.Unmarshal(vb)
+			if err != nil {
+				return err
+			}
+			m2[k] = v
+		case opDelete:
+			delete(m2, k)
+		default:
+			return fmt.Errorf("walmaps: unknown op %d", op)
+		}
+		return nil
+	}
+
+	if err := readSnapshot(filepath.Join(dir, snapshotName), apply); err != nil {
+		return nil, err
+	}
+
+	log, err := os.OpenFile(filepath.Join(dir, logName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := log.Stat()
+	var n int64
+	if err == nil {
+		n, err = readRecords(log, fi.Size(), func(op byte, kb, vb []byte) error {
+			m.records++
+			return apply(op, kb, vb)
+		})
+	}
+	if err == nil {
+		// Discard a torn tail so that new records follow the last valid one.
+		err = log.Truncate(n)
+	}
+	if err != nil {
+		log.Close()
+		return nil, err
+	}
+	m.log, m.size = log, n
+	m.m = cowmaps.CopyOnWrite(m2)
+	m.publish()
+
+	if m.opts.Sync == SyncInterval {
+		d := m.opts.Interval
+		if d <= 0 {
+			d = DefaultSyncInterval
+		}
+		m.stop = make(chan struct{})
+		m.wg.Add(1)
+		go m.syncLoop(d)
+	}
+	return m, nil
+}
+
+// readSnapshot calls f for each record of the snapshot at path, if it exists.
+func readSnapshot(path string, f func(op byte, k, v []byte) error) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	fi, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	n, err := readRecords(file, fi.Size(), f)
+	if err != nil {
+		return err
+	}
+	if n != fi.Size() {
+		return fmt.Errorf("%w at offset %d of %s", ErrCorrupt, n, path)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package walmaps
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_append_rollback(t *testing.T) {
+	dir := t.TempDir()
+	m, err := Open[string, int](dir, StringCodec{}, JSONCodec[int]{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetIndex("a", 1)
+
+	// fail the next write by swapping in a read-only log
+	log := m.log
+	if m.log, err = os.Open(filepath.Join(dir, logName)); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.SetIndex("b", 2); err == nil {
+		t.Errorf("SetIndex() = %v, want error", err)
+	}
+	m.log.Close()
+	m.log = log
+	if got, want := m.records, 1; got != want {
+		t.Errorf("records = %v, want %v", got, want)
+	}
+	if _, ok := m.Index("b"); ok {
+		t.Errorf("Index() = %v, want %v", ok, false)
+	}
+
+	// leave torn bytes after the last valid record, as a partial write would
+	if _, err = log.WriteAt([]byte("torn record bytes"), m.size); err != nil {
+		t.Fatal(err)
+	}
+	m.SetIndex("c", 3)
+	m.Close()
+
+	if m, err = Open[string, int](dir, StringCodec{}, JSONCodec[int]{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if got, want := m.String(), "map[a:1 c:3]"; got != want {
+		t.Errorf("Load() = %v, want %v", got, want)
+	}
+}
+
+func Test_maybeCompact_err(t *testing.T) {
+	dir := t.TempDir()
+	m, err := Open[string, int](dir, StringCodec{}, JSONCodec[int]{}, &Options{CompactEvery: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	// fail compaction by pointing at a missing directory
+	m.dir = filepath.Join(dir, "missing")
+	if err = m.SetIndex("a", 1); err != nil {
+		t.Errorf("SetIndex() = %v, want %v", err, nil)
+	}
+	if v, ok := m.Index("a"); v != 1 || !ok {
+		t.Errorf("Index() = (%v, %v), want (%v, %v)", v, ok, 1, true)
+	}
+	if err = m.Sync(); err == nil {
+		t.Errorf("Sync() = %v, want error", err)
+	}
+	if err = m.Sync(); err != nil {
+		t.Errorf("Sync() = %v, want %v", err, nil)
+	}
+}
+
+func Test_syncLoop_err(t *testing.T) {
+	dir := t.TempDir()
+	m, err := Open[string, int](dir, StringCodec{}, JSONCodec[int]{}, &Options{Sync: SyncInterval, Interval: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	// fail the periodic sync by swapping in a closed log
+	closed, err := os.Open(filepath.Join(dir, logName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed.Close()
+	m.mu.Lock()
+	log := m.log
+	m.log = closed
+	m.mu.Unlock()
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		m.mu.Lock()
+		failed := m.err != nil
+		if failed {
+			m.log = log
+		}
+		m.mu.Unlock()
+		if failed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("periodic sync did not fail")
+		}
+	}
+
+	if err = m.SetIndex("a", 1); err == nil {
+		t.Errorf("SetIndex() = %v, want error", err)
+	}
+	if _, ok := m.Index("a"); ok {
+		t.Errorf("Index() = %v, want %v", ok, false)
+	}
+	if err = m.SetIndex("a", 1); err != nil {
+		t.Errorf("SetIndex() = %v, want %v", err, nil)
+	}
+}
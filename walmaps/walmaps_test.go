@@ -0,0 +1,251 @@
+// Copyright 2022 phelmkamp. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+package walmaps_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/phelmkamp/immut/cowmaps"
+	"github.com/phelmkamp/immut/walmaps"
+)
+
+func Example() {
+	dir, _ := os.MkdirTemp("", "walmaps")
+	defer os.RemoveAll(dir)
+
+	m, _ := walmaps.Open[string, int](dir, walmaps.StringCodec{}, walmaps.JSONCodec[int]{}, nil)
+	_ = m.SetIndex("foo", 1)
+	_ = m.SetIndex("bar", 2)
+	_, _, _ = m.Delete("bar")
+	_ = m.Close()
+
+	m, _ = walmaps.Open[string, int](dir, walmaps.StringCodec{}, walmaps.JSONCodec[int]{}, nil)
+	defer m.Close()
+	fmt.Println(m.Load())
+	// Output: map[foo:1]
+}
+
+func open(t *testing.T, dir string, opts *walmaps.Options) *walmaps.Map[string, int] {
+	t.Helper()
+	m, err := walmaps.Open[string, int](dir, walmaps.StringCodec{}, walmaps.JSONCodec[int]{}, opts)
+	if err != nil {
+		t.Fatalf("Open() = %v, want %v", err, nil)
+	}
+	return m
+}
+
+func TestOpen_tornTail(t *testing.T) {
+	dir := t.TempDir()
+	m := open(t, dir, &walmaps.Options{CompactEvery: -1})
+	for i, k := range []string{"a", "b", "c"} {
+		if err := m.SetIndex(k, i); err != nil {
+			t.Fatalf("SetIndex() = %v, want %v", err, nil)
+		}
+	}
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close() = %v, want %v", err, nil)
+	}
+
+	// simulate a crash in the middle of the last write
+	log := filepath.Join(dir, "wal")
+	fi, err := os.Stat(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Truncate(log, fi.Size()-3); err != nil {
+		t.Fatal(err)
+	}
+
+	m = open(t, dir, nil)
+	if got, want := m.String(), "map[a:0 b:1]"; got != want {
+		t.Errorf("Load() = %v, want %v", got, want)
+	}
+	// new writes follow the last valid record
+	if err = m.SetIndex("d", 3); err != nil {
+		t.Fatalf("SetIndex() = %v, want %v", err, nil)
+	}
+	m.Close()
+	m = open(t, dir, nil)
+	defer m.Close()
+	if got, want := m.String(), "map[a:0 b:1 d:3]"; got != want {
+		t.Errorf("Load() = %v, want %v", got, want)
+	}
+}
+
+func TestOpen_tornHeader(t *testing.T) {
+	dir := t.TempDir()
+	m := open(t, dir, nil)
+	m.SetIndex("a", 1)
+	m.Close()
+
+	// a torn header must not be trusted to allocate its payload
+	f, err := os.OpenFile(filepath.Join(dir, "wal"), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{0, 0, 0, 0, 0xf0, 0xff, 0xff, 0xff})
+	f.Close()
+
+	m = open(t, dir, nil)
+	defer m.Close()
+	if got, want := m.String(), "map[a:1]"; got != want {
+		t.Errorf("Load() = %v, want %v", got, want)
+	}
+}
+
+func TestOpen_corruptSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	m := open(t, dir, nil)
+	m.SetIndex("a", 1)
+	m.SetIndex("b", 2)
+	if err := m.Compact(); err != nil {
+		t.Fatalf("Compact() = %v, want %v", err, nil)
+	}
+	m.Close()
+
+	snap := filepath.Join(dir, "snapshot")
+	b, err := os.ReadFile(snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(snap, b[:len(b)-1], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = walmaps.Open[string, int](dir, walmaps.StringCodec{}, walmaps.JSONCodec[int]{}, nil); !errors.Is(err, walmaps.ErrCorrupt) {
+		t.Errorf("Open() = %v, want %v", err, walmaps.ErrCorrupt)
+	}
+}
+
+func TestOpen_corrupt(t *testing.T) {
+	dir := t.TempDir()
+	m := open(t, dir, &walmaps.Options{Sync: walmaps.SyncNever})
+	m.SetIndex("a", 1)
+	m.SetIndex("b", 2)
+	m.Close()
+
+	// flip a byte in the value of the last record
+	log := filepath.Join(dir, "wal")
+	b, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[len(b)-1] ^= 0xff
+	if err = os.WriteFile(log, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m = open(t, dir, nil)
+	defer m.Close()
+	if got, want := m.String(), "map[a:1]"; got != want {
+		t.Errorf("Load() = %v, want %v", got, want)
+	}
+}
+
+func TestMap_compact(t *testing.T) {
+	dir := t.TempDir()
+	m := open(t, dir, &walmaps.Options{CompactEvery: 3})
+	for i := 0; i < 10; i++ {
+		if err := m.SetIndex(fmt.Sprint(i%4), i); err != nil {
+			t.Fatalf("SetIndex() = %v, want %v", err, nil)
+		}
+	}
+	m.Delete("0")
+	want := m.String()
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close() = %v, want %v", err, nil)
+	}
+
+	// 11 records with compaction every 3 leaves 2 in the log
+	fi, err := os.Stat(filepath.Join(dir, "wal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Size() == 0 {
+		t.Errorf("log size = %v, want > 0", fi.Size())
+	}
+	if _, err = os.Stat(filepath.Join(dir, "snapshot")); err != nil {
+		t.Errorf("Stat() = %v, want %v", err, nil)
+	}
+
+	m = open(t, dir, nil)
+	if got := m.String(); got != want {
+		t.Errorf("Load() = %v, want %v", got, want)
+	}
+	if err = m.Compact(); err != nil {
+		t.Fatalf("Compact() = %v, want %v", err, nil)
+	}
+	m.Close()
+	if fi, _ = os.Stat(filepath.Join(dir, "wal")); fi.Size() != 0 {
+		t.Errorf("log size = %v, want %v", fi.Size(), 0)
+	}
+	m = open(t, dir, nil)
+	defer m.Close()
+	if got := m.String(); got != want {
+		t.Errorf("Load() = %v, want %v", got, want)
+	}
+}
+
+func TestMap_Load(t *testing.T) {
+	m := open(t, t.TempDir(), &walmaps.Options{Sync: walmaps.SyncInterval})
+	defer m.Close()
+	m.SetIndex("a", 1)
+	ro := m.Load()
+	m.SetIndex("a", 2)
+	m.SetIndex("b", 3)
+	if got, want := ro.String(), "map[a:1]"; got != want {
+		t.Errorf("Load() = %v, want %v", got, want)
+	}
+	if v, ok := m.Index("a"); v != 2 || !ok {
+		t.Errorf("Index() = (%v, %v), want (%v, %v)", v, ok, 2, true)
+	}
+	if got, want := m.Len(), 2; got != want {
+		t.Errorf("Len() = %v, want %v", got, want)
+	}
+}
+
+func TestMap_SetIndex_clones(t *testing.T) {
+	cowmaps.EnableStats(true)
+	defer cowmaps.EnableStats(false)
+	cowmaps.ResetStats()
+	defer cowmaps.ResetStats()
+
+	m := open(t, t.TempDir(), &walmaps.Options{Sync: walmaps.SyncNever})
+	defer m.Close()
+	for i := 0; i < 10; i++ {
+		m.SetIndex(strconv.Itoa(i), i)
+	}
+	ro := m.Load()
+	m.SetIndex("a", 10)
+	m.Delete("0")
+	if got, want := ro.Len(), 10; got != want {
+		t.Errorf("Len() = %v, want %v", got, want)
+	}
+	if got, want := m.Len(), 10; got != want {
+		t.Errorf("Len() = %v, want %v", got, want)
+	}
+	want := map[string]cowmaps.OpStats{"Transient": {Clones: 2, Copied: 10}}
+	if got := cowmaps.Stats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Stats() = %v, want %v", got, want)
+	}
+}
+
+func TestMap_Close(t *testing.T) {
+	m := open(t, t.TempDir(), &walmaps.Options{Sync: walmaps.SyncInterval})
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close() = %v, want %v", err, nil)
+	}
+	if err := m.SetIndex("a", 1); !errors.Is(err, walmaps.ErrClosed) {
+		t.Errorf("SetIndex() = %v, want %v", err, walmaps.ErrClosed)
+	}
+	if err := m.Close(); !errors.Is(err, walmaps.ErrClosed) {
+		t.Errorf("Close() = %v, want %v", err, walmaps.ErrClosed)
+	}
+}